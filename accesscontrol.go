@@ -0,0 +1,51 @@
+package phphttpd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AccessRule limits a request path prefix to the clients in Allow, minus the
+// clients in Deny. Client addresses are the ones resolved by mod_remoteip.
+type AccessRule struct {
+	Path  string
+	Allow []string
+	Deny  []string
+}
+
+func loadAccessRules(s settings) ([]AccessRule, error) {
+	entries, err := s.lookupKeyValues("BP_PHP_HTTPD_ACCESS_CONTROL")
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []AccessRule
+	for _, entry := range entries {
+		if !isURLPath(entry.Key) {
+			return nil, fmt.Errorf("failed to parse %s: %q is not a path prefix", s.describe("BP_PHP_HTTPD_ACCESS_CONTROL"), entry.Key)
+		}
+
+		rule := AccessRule{Path: entry.Key}
+		for _, address := range strings.Fields(entry.Value) {
+			deny := strings.HasPrefix(address, "!")
+			address = strings.TrimPrefix(address, "!")
+			if !isIPOrCIDR(address) {
+				return nil, fmt.Errorf("failed to parse %s: invalid IP address or CIDR for %s: %q", s.describe("BP_PHP_HTTPD_ACCESS_CONTROL"), entry.Key, address)
+			}
+
+			if deny {
+				rule.Deny = append(rule.Deny, address)
+			} else {
+				rule.Allow = append(rule.Allow, address)
+			}
+		}
+
+		if len(rule.Allow) == 0 && len(rule.Deny) == 0 {
+			return nil, fmt.Errorf("failed to parse %s: no addresses for %s", s.describe("BP_PHP_HTTPD_ACCESS_CONTROL"), entry.Key)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
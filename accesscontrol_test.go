@@ -0,0 +1,119 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testAccessControl(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+
+		config = phphttpd.NewConfig(scribe.NewEmitter(bytes.NewBuffer(nil)))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("does not restrict any path by default", func() {
+		metadata, err := config.Write(layerDir, workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(metadata.ConfigPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).NotTo(ContainSubstring("RequireAll"))
+	})
+
+	context("when $BP_PHP_HTTPD_ACCESS_CONTROL is set", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_ACCESS_CONTROL", "/admin=10.0.0.0/8 192.168.0.0/16 !10.1.0.0/16, /wp-admin=!203.0.113.7")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_ACCESS_CONTROL")).To(Succeed())
+		})
+
+		it("renders a Location block per path", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`<Location "/admin">
+    <RequireAll>
+        Require ip 10.0.0.0/8 192.168.0.0/16
+        Require not ip 10.1.0.0/16
+    </RequireAll>
+</Location>
+<Location "/wp-admin">
+    <RequireAll>
+        Require all granted
+        Require not ip 203.0.113.7
+    </RequireAll>
+</Location>`))
+		})
+	})
+
+	context("failure cases", func() {
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_ACCESS_CONTROL")).To(Succeed())
+		})
+
+		context("when the path is not a path prefix", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_ACCESS_CONTROL", "admin=10.0.0.0/8")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`"admin" is not a path prefix`)))
+			})
+		})
+
+		context("when an address is invalid", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_ACCESS_CONTROL", "/admin=10.0.0.0/33")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`invalid IP address or CIDR for /admin: "10.0.0.0/33"`)))
+			})
+		})
+
+		context("when a path has no addresses", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_ACCESS_CONTROL", "/admin=")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("no addresses for /admin")))
+			})
+		})
+	})
+}
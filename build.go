@@ -1,6 +1,8 @@
 package phphttpd
 
 import (
+	"path/filepath"
+
 	"github.com/paketo-buildpacks/packit/v2"
 	"github.com/paketo-buildpacks/packit/v2/draft"
 	"github.com/paketo-buildpacks/packit/v2/scribe"
@@ -9,9 +11,10 @@ import (
 //go:generate faux --interface ConfigWriter --output fakes/config_writer.go
 
 // ConfigWriter sets up the HTTPD configuration file with defaults, and adds in
-// user-set environment variables.
+// user-set environment variables. It returns the Metadata of the
+// configuration, which includes the path of the configuration file.
 type ConfigWriter interface {
-	Write(layerPath, workingDir string) (string, error)
+	Write(layerPath, workingDir string) (Metadata, error)
 }
 
 // Build will return a packit.BuildFunc that will be invoked during the build
@@ -39,20 +42,42 @@ func Build(config ConfigWriter, logger scribe.Emitter) packit.BuildFunc {
 		}
 
 		logger.Process("Setting up the HTTPD configuration file")
-		httpdConfigPath, err := config.Write(phpHttpdLayer.Path, context.WorkingDir)
+		metadata, err := config.Write(phpHttpdLayer.Path, context.WorkingDir)
 		if err != nil {
 			return packit.BuildResult{}, err
 		}
 		logger.Break()
 
+		phpHttpdLayer.Metadata = metadata.LayerMetadata()
+
 		planner := draft.NewPlanner()
 		phpHttpdLayer.Launch, phpHttpdLayer.Build = planner.MergeLayerTypes(PhpHttpdConfig, context.Plan.Entries)
 
-		phpHttpdLayer.SharedEnv.Default("PHP_HTTPD_PATH", httpdConfigPath)
+		phpHttpdLayer.SharedEnv.Default("PHP_HTTPD_PATH", metadata.ConfigPath)
+		phpHttpdLayer.SharedEnv.Default("PHP_HTTPD_METADATA_PATH", filepath.Join(phpHttpdLayer.Path, MetadataFile))
 		logger.EnvironmentVariables(phpHttpdLayer)
 
-		return packit.BuildResult{
+		result := packit.BuildResult{
 			Layers: []packit.Layer{phpHttpdLayer},
-		}, nil
+		}
+
+		if phpHttpdLayer.Launch || phpHttpdLayer.Build {
+			logger.GeneratingSBOM(phpHttpdLayer.Path)
+			logger.FormattingSBOM(context.BuildpackInfo.SBOMFormats...)
+
+			sbomFormatter, err := GenerateSBOM(metadata, context.BuildpackInfo.Version, phpHttpdLayer.Path).InFormats(context.BuildpackInfo.SBOMFormats...)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			if phpHttpdLayer.Launch {
+				result.Launch.SBOM = sbomFormatter
+			}
+			if phpHttpdLayer.Build {
+				result.Build.SBOM = sbomFormatter
+			}
+		}
+
+		return result, nil
 	}
 }
@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/paketo-buildpacks/packit/v2"
 	"github.com/paketo-buildpacks/packit/v2/scribe"
 	phphttpd "github.com/paketo-buildpacks/php-httpd"
@@ -49,15 +52,24 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		logEmitter := scribe.NewEmitter(buffer)
 
 		config = &fakes.ConfigWriter{}
-		config.WriteCall.Returns.String = "some-workspace/httpd.conf"
+		config.WriteCall.Returns.Metadata = phphttpd.Metadata{
+			ConfigPath:    "some-workspace/httpd.conf",
+			WebDirectory:  "htdocs",
+			DocumentRoot:  "some-workspace/htdocs",
+			FpmSocket:     "127.0.0.1:9000",
+			HTTPSRedirect: true,
+			Modules:       []string{"rewrite"},
+			HealthCheck:   &phphttpd.HealthCheckMetadata{Path: "/healthz"},
+		}
 
 		buildContext = packit.BuildContext{
 			WorkingDir: workingDir,
 			CNBPath:    cnbDir,
 			Stack:      "some-stack",
 			BuildpackInfo: packit.BuildpackInfo{
-				Name:    "Some Buildpack",
-				Version: "some-version",
+				Name:        "Some Buildpack",
+				Version:     "some-version",
+				SBOMFormats: []string{phphttpd.CycloneDXFormat, phphttpd.SPDXFormat, phphttpd.SyftFormat},
 			},
 			Plan: packit.BuildpackPlan{
 				Entries: []packit.BuildpackPlanEntry{
@@ -76,11 +88,21 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			Launch: false,
 			Cache:  false,
 			SharedEnv: packit.Environment{
-				"PHP_HTTPD_PATH.default": "some-workspace/httpd.conf",
+				"PHP_HTTPD_PATH.default":          "some-workspace/httpd.conf",
+				"PHP_HTTPD_METADATA_PATH.default": filepath.Join(layerDir, phphttpd.PhpHttpdConfigLayer, phphttpd.MetadataFile),
 			},
 			BuildEnv:         packit.Environment{},
 			LaunchEnv:        packit.Environment{},
 			ProcessLaunchEnv: map[string]packit.Environment{},
+			Metadata: map[string]interface{}{
+				"config_path":       "some-workspace/httpd.conf",
+				"web_directory":     "htdocs",
+				"document_root":     "some-workspace/htdocs",
+				"fpm_socket":        "127.0.0.1:9000",
+				"https_redirect":    true,
+				"modules":           []string{"rewrite"},
+				"health_check_path": "/healthz",
+			},
 		}
 
 		build = phphttpd.Build(config, logEmitter)
@@ -100,6 +122,8 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 
 		Expect(result.Layers).To(HaveLen(1))
 		Expect(result.Layers[0]).To(Equal(expectedPhpLayer))
+		Expect(result.Launch.SBOM).To(BeNil())
+		Expect(result.Build.SBOM).To(BeNil())
 	})
 
 	context("when httpd-config is required at launch time", func() {
@@ -118,6 +142,75 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			Expect(result.Layers).To(HaveLen(1))
 			Expect(result.Layers[0]).To(Equal(expectedPhpLayer))
 		})
+
+		it("generates a launch SBOM for the layer", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Build.SBOM).To(BeNil())
+			formats := result.Launch.SBOM.Formats()
+			Expect(formats).To(HaveLen(3))
+
+			Expect(formats[0].Extension).To(Equal("cdx.json"))
+			content, err := io.ReadAll(formats[0].Content)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring(`"name": "php-httpd-config"`))
+			Expect(string(content)).To(ContainSubstring(`"version": "some-version"`))
+			Expect(string(content)).To(ContainSubstring(`"name": "mod_rewrite"`))
+
+			Expect(formats[1].Extension).To(Equal("spdx.json"))
+			content, err = io.ReadAll(formats[1].Content)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring(`"name": "mod_rewrite"`))
+			Expect(string(content)).To(ContainSubstring(`"relationshipType": "DESCRIBES"`))
+
+			Expect(formats[2].Extension).To(Equal("syft.json"))
+			content, err = io.ReadAll(formats[2].Content)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring(`"path": "some-workspace/httpd.conf"`))
+			Expect(string(content)).To(ContainSubstring(`"type": "binary"`))
+			Expect(string(content)).NotTo(ContainSubstring(`"type": "httpd-module"`))
+
+			Expect(buffer.String()).To(ContainSubstring("Generating SBOM for " + filepath.Join(layerDir, phphttpd.PhpHttpdConfigLayer)))
+		})
+
+		it("generates the same SBOM on every build", func() {
+			first, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			time.Sleep(1100 * time.Millisecond)
+
+			second, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			firstFormats := first.Launch.SBOM.Formats()
+			secondFormats := second.Launch.SBOM.Formats()
+			Expect(secondFormats).To(HaveLen(len(firstFormats)))
+			for i := range firstFormats {
+				firstContent, err := io.ReadAll(firstFormats[i].Content)
+				Expect(err).NotTo(HaveOccurred())
+
+				secondContent, err := io.ReadAll(secondFormats[i].Content)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(secondContent).To(Equal(firstContent), firstFormats[i].Extension)
+			}
+		})
+	})
+
+	it("declares the SBOM formats it generates in buildpack.toml", func() {
+		var buildpack struct {
+			Buildpack struct {
+				SBOMFormats []string `toml:"sbom-formats"`
+			} `toml:"buildpack"`
+		}
+		_, err := toml.DecodeFile("buildpack.toml", &buildpack)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(buildpack.Buildpack.SBOMFormats).To(ConsistOf(phphttpd.CycloneDXFormat, phphttpd.SPDXFormat, phphttpd.SyftFormat))
+
+		_, err = phphttpd.GenerateSBOM(phphttpd.Metadata{}, "some-version", layerDir).InFormats(buildpack.Buildpack.SBOMFormats...)
+		Expect(err).NotTo(HaveOccurred())
 	})
 
 	context("failure cases", func() {
@@ -143,5 +236,19 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 				Expect(err).To(MatchError(ContainSubstring("config writing error")))
 			})
 		})
+
+		context("when the SBOM format is not supported", func() {
+			it.Before(func() {
+				buildContext.Plan.Entries[0].Metadata = map[string]interface{}{
+					"launch": true,
+				}
+				buildContext.BuildpackInfo.SBOMFormats = []string{"application/vnd.unknown+json"}
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(ContainSubstring("unsupported SBOM format")))
+			})
+		})
 	})
 }
@@ -0,0 +1,167 @@
+package phphttpd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// defaultCachePolicies are enabled by $BP_PHP_HTTPD_ENABLE_CACHE_DEFAULTS.
+// User-provided policies for the same match replace them.
+var defaultCachePolicies = []keyValue{
+	{Key: "css|js", Value: "7d"},
+	{Key: "avif|gif|ico|jpeg|jpg|png|svg|webp", Value: "30d"},
+	{Key: "eot|otf|ttf|woff|woff2", Value: "30d"},
+}
+
+// CachePolicy sets the Cache-Control and Expires headers for static files
+// matched either by a request path prefix or by a list of file extensions.
+type CachePolicy struct {
+	PathPrefix string
+	Extensions []string
+	MaxAge     int
+	Immutable  bool
+	NoCache    bool
+}
+
+// Section returns the name of the HTTPD configuration section that the
+// policy is rendered in.
+func (p CachePolicy) Section() string {
+	if p.PathPrefix != "" {
+		return "LocationMatch"
+	}
+	return "FilesMatch"
+}
+
+// Pattern returns the regular expression the policy section matches on.
+func (p CachePolicy) Pattern() string {
+	if p.PathPrefix != "" {
+		return "^" + regexp.QuoteMeta(p.PathPrefix)
+	}
+	return fmt.Sprintf(`\.(?i:%s)$`, strings.Join(p.Extensions, "|"))
+}
+
+// CacheControl returns the value of the Cache-Control header.
+func (p CachePolicy) CacheControl() string {
+	if p.NoCache {
+		return "no-cache"
+	}
+
+	value := fmt.Sprintf("public, max-age=%d", p.MaxAge)
+	if p.Immutable {
+		value += ", immutable"
+	}
+	return value
+}
+
+func loadCachePolicies(s settings) ([]CachePolicy, error) {
+	enableDefaults, err := s.lookupBool("BP_PHP_HTTPD_ENABLE_CACHE_DEFAULTS", false)
+	if err != nil {
+		return nil, err
+	}
+
+	userPolicies, err := s.lookupKeyValues("BP_PHP_HTTPD_CACHE_POLICIES")
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []keyValue
+	if enableDefaults {
+		for _, entry := range defaultCachePolicies {
+			if !containsKey(userPolicies, entry.Key) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	entries = append(entries, userPolicies...)
+
+	var policies []CachePolicy
+	for _, entry := range entries {
+		policy, err := parseCachePolicy(entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", s.describe("BP_PHP_HTTPD_CACHE_POLICIES"), err)
+		}
+		policies = append(policies, policy)
+	}
+
+	return policies, nil
+}
+
+func parseCachePolicy(entry keyValue) (CachePolicy, error) {
+	var policy CachePolicy
+	if strings.HasPrefix(entry.Key, "/") {
+		if !isURLPath(entry.Key) {
+			return CachePolicy{}, fmt.Errorf("path prefix %q must not contain quotes or whitespace", entry.Key)
+		}
+		policy.PathPrefix = entry.Key
+	} else {
+		for _, extension := range strings.Split(entry.Key, "|") {
+			extension = strings.TrimPrefix(strings.TrimSpace(extension), ".")
+			if extension == "" || strings.ContainsAny(extension, `/\.*?()[]{}^$"`) {
+				return CachePolicy{}, fmt.Errorf("%q is neither a path prefix nor a list of file extensions", entry.Key)
+			}
+			policy.Extensions = append(policy.Extensions, extension)
+		}
+	}
+
+	fields := strings.Fields(entry.Value)
+	if len(fields) == 0 {
+		return CachePolicy{}, fmt.Errorf("missing policy for %q", entry.Key)
+	}
+
+	if fields[0] == "no-cache" {
+		policy.NoCache = true
+	} else {
+		maxAge, err := parseMaxAge(fields[0])
+		if err != nil {
+			return CachePolicy{}, fmt.Errorf("invalid max-age for %q: %w", entry.Key, err)
+		}
+		policy.MaxAge = maxAge
+	}
+
+	for _, field := range fields[1:] {
+		if field != "immutable" || policy.NoCache {
+			return CachePolicy{}, fmt.Errorf("unsupported cache option %q for %q", field, entry.Key)
+		}
+		policy.Immutable = true
+	}
+
+	return policy, nil
+}
+
+// parseMaxAge parses a number of seconds with an optional s, m, h, d, w or y
+// unit suffix.
+func parseMaxAge(value string) (int, error) {
+	units := map[byte]int{
+		's': 1,
+		'm': 60,
+		'h': 60 * 60,
+		'd': 24 * 60 * 60,
+		'w': 7 * 24 * 60 * 60,
+		'y': 365 * 24 * 60 * 60,
+	}
+
+	number, multiplier := value, 1
+	if len(value) > 0 {
+		if unit, ok := units[value[len(value)-1]]; ok {
+			number, multiplier = value[:len(value)-1], unit
+		}
+	}
+
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a duration", value)
+	}
+
+	return n * multiplier, nil
+}
+
+func containsKey(entries []keyValue, key string) bool {
+	for _, entry := range entries {
+		if entry.Key == key {
+			return true
+		}
+	}
+	return false
+}
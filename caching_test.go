@@ -0,0 +1,221 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testCaching(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+
+		config = phphttpd.NewConfig(scribe.NewEmitter(bytes.NewBuffer(nil)))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("does not set caching headers by default", func() {
+		metadata, err := config.Write(layerDir, workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(metadata.ConfigPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).NotTo(ContainSubstring("expires_module"))
+		Expect(string(contents)).NotTo(ContainSubstring("Cache-Control"))
+	})
+
+	context("when $BP_PHP_HTTPD_ENABLE_CACHE_DEFAULTS is true", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_ENABLE_CACHE_DEFAULTS", "true")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_ENABLE_CACHE_DEFAULTS")).To(Succeed())
+		})
+
+		it("renders the default cache policies", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("LoadModule expires_module modules/mod_expires.so"))
+			Expect(string(contents)).To(ContainSubstring("ExpiresActive On"))
+			Expect(string(contents)).To(ContainSubstring(`<FilesMatch "\.(?i:css|js)$">
+    ExpiresDefault "access plus 604800 seconds"
+    Header set Cache-Control "public, max-age=604800"
+</FilesMatch>`))
+			Expect(string(contents)).To(ContainSubstring(`<FilesMatch "\.(?i:avif|gif|ico|jpeg|jpg|png|svg|webp)$">
+    ExpiresDefault "access plus 2592000 seconds"
+    Header set Cache-Control "public, max-age=2592000"
+</FilesMatch>`))
+		})
+
+		context("when a user policy has the same match as a default", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_CACHE_POLICIES", "css|js=1h")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_CACHE_POLICIES")).To(Succeed())
+			})
+
+			it("replaces the default", func() {
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring(`Header set Cache-Control "public, max-age=3600"`))
+				Expect(string(contents)).NotTo(ContainSubstring(`Header set Cache-Control "public, max-age=604800"`))
+			})
+		})
+	})
+
+	context("when $BP_PHP_HTTPD_CACHE_POLICIES is set", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_CACHE_POLICIES", "/build/=1y immutable, .png|.jpg=3600, /api/=no-cache")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_CACHE_POLICIES")).To(Succeed())
+		})
+
+		it("renders a section per policy", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`<LocationMatch "^/build/">
+    ExpiresDefault "access plus 31536000 seconds"
+    Header set Cache-Control "public, max-age=31536000, immutable"
+</LocationMatch>`))
+			Expect(string(contents)).To(ContainSubstring(`<FilesMatch "\.(?i:png|jpg)$">
+    ExpiresDefault "access plus 3600 seconds"
+    Header set Cache-Control "public, max-age=3600"
+</FilesMatch>`))
+			Expect(string(contents)).To(ContainSubstring(`<LocationMatch "^/api/">
+    Header set Cache-Control "no-cache"
+</LocationMatch>`))
+			Expect(string(contents)).NotTo(ContainSubstring("(?i:css|js)"))
+		})
+	})
+
+	context("failure cases", func() {
+		context("when $BP_PHP_HTTPD_ENABLE_CACHE_DEFAULTS cannot be parsed into a bool", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_ENABLE_CACHE_DEFAULTS", "blah")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_ENABLE_CACHE_DEFAULTS")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse $BP_PHP_HTTPD_ENABLE_CACHE_DEFAULTS into boolean")))
+			})
+		})
+
+		context("when a path prefix contains a quote", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_CACHE_POLICIES", `/build"x=1d`)).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_CACHE_POLICIES")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`path prefix "/build\"x" must not contain quotes or whitespace`)))
+			})
+		})
+
+		context("when a policy is not a key=value pair", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_CACHE_POLICIES", "/build/")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_CACHE_POLICIES")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`entry "/build/" is not of the form key=value`)))
+			})
+		})
+
+		context("when a policy matches neither a path nor extensions", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_CACHE_POLICIES", "image/*=1d")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_CACHE_POLICIES")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`"image/*" is neither a path prefix nor a list of file extensions`)))
+			})
+		})
+
+		context("when a max-age is not a duration", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_CACHE_POLICIES", "css=forever")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_CACHE_POLICIES")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`invalid max-age for "css": "forever" is not a duration`)))
+			})
+		})
+
+		context("when a policy has an unsupported option", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_CACHE_POLICIES", "css=1d private")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_CACHE_POLICIES")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`unsupported cache option "private" for "css"`)))
+			})
+		})
+	})
+}
@@ -0,0 +1,104 @@
+package phphttpd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var defaultCompressionTypes = []string{
+	"text/html",
+	"text/plain",
+	"text/xml",
+	"text/css",
+	"text/javascript",
+	"application/javascript",
+	"application/json",
+	"image/svg+xml",
+}
+
+type precompressedType struct {
+	Extension string
+	Type      string
+}
+
+// precompressedTypes are the file types for which precompressed .br and .gz
+// siblings are served when they exist.
+var precompressedTypes = []precompressedType{
+	{Extension: "css", Type: "text/css"},
+	{Extension: "html", Type: "text/html"},
+	{Extension: "js", Type: "text/javascript"},
+	{Extension: "json", Type: "application/json"},
+	{Extension: "mjs", Type: "text/javascript"},
+	{Extension: "svg", Type: "image/svg+xml"},
+	{Extension: "txt", Type: "text/plain"},
+	{Extension: "wasm", Type: "application/wasm"},
+	{Extension: "xml", Type: "application/xml"},
+}
+
+var mimeTypePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9!#$&^_.+-]*/[a-zA-Z0-9][a-zA-Z0-9!#$&^_.+-]*$`)
+
+// Compression configures response compression with mod_deflate and,
+// optionally, mod_brotli, as well as serving precompressed files.
+type Compression struct {
+	Types         []string
+	Level         int
+	Brotli        bool
+	BrotliQuality int
+	Precompressed bool
+}
+
+// PrecompressedTypes returns the file types for which precompressed siblings
+// are served.
+func (c Compression) PrecompressedTypes() []precompressedType {
+	return precompressedTypes
+}
+
+// PrecompressedExtensions returns the file extensions for which precompressed
+// siblings are served, as a regular expression alternation.
+func (c Compression) PrecompressedExtensions() string {
+	var extensions []string
+	for _, t := range precompressedTypes {
+		extensions = append(extensions, t.Extension)
+	}
+	return strings.Join(extensions, "|")
+}
+
+func loadCompression(s settings) (Compression, error) {
+	compression := Compression{
+		Types: s.lookupList("BP_PHP_HTTPD_COMPRESSION_TYPES"),
+	}
+
+	if len(compression.Types) == 0 {
+		compression.Types = defaultCompressionTypes
+	}
+
+	for _, t := range compression.Types {
+		if !mimeTypePattern.MatchString(t) {
+			return Compression{}, fmt.Errorf("%s contains an invalid MIME type: %q", s.describe("BP_PHP_HTTPD_COMPRESSION_TYPES"), t)
+		}
+	}
+
+	var err error
+	compression.Level, err = s.lookupInt("BP_PHP_HTTPD_COMPRESSION_LEVEL", 6, 1, 9)
+	if err != nil {
+		return Compression{}, err
+	}
+
+	compression.Brotli, err = s.lookupBool("BP_PHP_HTTPD_ENABLE_BROTLI", false)
+	if err != nil {
+		return Compression{}, err
+	}
+
+	compression.BrotliQuality, err = s.lookupInt("BP_PHP_HTTPD_BROTLI_QUALITY", 5, 0, 11)
+	if err != nil {
+		return Compression{}, err
+	}
+
+	compression.Precompressed, err = s.lookupBool("BP_PHP_HTTPD_SERVE_PRECOMPRESSED", false)
+	if err != nil {
+		return Compression{}, err
+	}
+
+	return compression, nil
+}
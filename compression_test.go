@@ -0,0 +1,238 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testCompression(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+
+		config = phphttpd.NewConfig(scribe.NewEmitter(bytes.NewBuffer(nil)))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("compresses the default MIME types with deflate", func() {
+		metadata, err := config.Write(layerDir, workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(metadata.ConfigPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(ContainSubstring("DeflateCompressionLevel 6"))
+		Expect(string(contents)).To(ContainSubstring("AddOutputFilterByType DEFLATE text/html text/plain text/xml text/css text/javascript application/javascript application/json image/svg+xml"))
+		Expect(string(contents)).NotTo(ContainSubstring("brotli"))
+		Expect(string(contents)).NotTo(ContainSubstring("precompressed"))
+	})
+
+	context("when the compression types and level are set", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_COMPRESSION_TYPES", "text/html,application/ld+json")).To(Succeed())
+			Expect(os.Setenv("BP_PHP_HTTPD_COMPRESSION_LEVEL", "9")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_COMPRESSION_TYPES")).To(Succeed())
+			Expect(os.Unsetenv("BP_PHP_HTTPD_COMPRESSION_LEVEL")).To(Succeed())
+		})
+
+		it("uses them", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("DeflateCompressionLevel 9"))
+			Expect(string(contents)).To(ContainSubstring("AddOutputFilterByType DEFLATE text/html application/ld+json\n"))
+		})
+	})
+
+	context("when $BP_PHP_HTTPD_ENABLE_BROTLI is true", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_ENABLE_BROTLI", "true")).To(Succeed())
+			Expect(os.Setenv("BP_PHP_HTTPD_BROTLI_QUALITY", "11")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_ENABLE_BROTLI")).To(Succeed())
+			Expect(os.Unsetenv("BP_PHP_HTTPD_BROTLI_QUALITY")).To(Succeed())
+		})
+
+		it("prefers brotli and falls back to deflate", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("LoadModule brotli_module modules/mod_brotli.so"))
+			Expect(string(contents)).To(ContainSubstring("BrotliCompressionQuality 11"))
+			Expect(string(contents)).To(ContainSubstring("AddOutputFilterByType BROTLI_COMPRESS;DEFLATE text/html"))
+			Expect(string(contents)).NotTo(ContainSubstring("AddOutputFilterByType DEFLATE"))
+		})
+	})
+
+	context("when $BP_PHP_HTTPD_SERVE_PRECOMPRESSED is true", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_SERVE_PRECOMPRESSED", "true")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_SERVE_PRECOMPRESSED")).To(Succeed())
+		})
+
+		it("serves gzip siblings of static files", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`
+RewriteCond "%{HTTP:Accept-Encoding}" "gzip"
+RewriteCond "%{DOCUMENT_ROOT}%{REQUEST_URI}.gz" -s
+RewriteRule "^(.+)\.(css|html|js|json|mjs|svg|txt|wasm|xml)$" "$1.$2.gz" [QSA,PT,L,E=no-brotli:1,E=no-gzip:1]`))
+			Expect(string(contents)).To(ContainSubstring(`    <FilesMatch "\.css\.(br|gz)$">
+        ForceType text/css
+    </FilesMatch>`))
+			Expect(string(contents)).To(ContainSubstring(`    <FilesMatch "\.(css|html|js|json|mjs|svg|txt|wasm|xml)\.gz$">
+        Header append Content-Encoding gzip
+        Header append Vary Accept-Encoding
+    </FilesMatch>`))
+			Expect(string(contents)).NotTo(ContainSubstring(`"$1.$2.br"`))
+		})
+
+		context("and brotli is enabled", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_ENABLE_BROTLI", "true")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_ENABLE_BROTLI")).To(Succeed())
+			})
+
+			it("also serves brotli siblings", func() {
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring(`
+RewriteCond "%{HTTP:Accept-Encoding}" "br"
+RewriteCond "%{DOCUMENT_ROOT}%{REQUEST_URI}.br" -s
+RewriteRule "^(.+)\.(css|html|js|json|mjs|svg|txt|wasm|xml)$" "$1.$2.br" [QSA,PT,L,E=no-brotli:1,E=no-gzip:1]`))
+			})
+		})
+
+		context("and the web directory has a .htaccess file that rewrites", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "htdocs", ".htaccess"), []byte("RewriteEngine On\nRewriteCond %{REQUEST_FILENAME} !-f\nRewriteRule ^ index.php [L]\n"), 0600)).To(Succeed())
+			})
+
+			it("keeps the rewrite rules out of the web directory's <Directory> sections", func() {
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring("AllowOverride All"))
+
+				directory := regexp.MustCompile(`(?s)\n<Directory "` + regexp.QuoteMeta(filepath.Join(workingDir, "htdocs")) + `">\n.*?\n</Directory>`)
+				sections := directory.FindAllString(string(contents), -1)
+				Expect(sections).NotTo(BeEmpty())
+				for _, section := range sections {
+					Expect(section).NotTo(ContainSubstring("Rewrite"))
+				}
+				Expect(string(contents)).To(MatchRegexp(`\nRewriteCond "%\{DOCUMENT_ROOT\}%\{REQUEST_URI\}\.gz" -s\n`))
+			})
+		})
+	})
+
+	context("failure cases", func() {
+		context("when a compression type is not a MIME type", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_COMPRESSION_TYPES", "text/html json")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_COMPRESSION_TYPES")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`$BP_PHP_HTTPD_COMPRESSION_TYPES contains an invalid MIME type: "json"`)))
+			})
+		})
+
+		context("when the compression level is out of range", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_COMPRESSION_LEVEL", "10")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_COMPRESSION_LEVEL")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`$BP_PHP_HTTPD_COMPRESSION_LEVEL must be a number between 1 and 9: "10"`)))
+			})
+		})
+
+		context("when the brotli quality is not a number", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_BROTLI_QUALITY", "best")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_BROTLI_QUALITY")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`$BP_PHP_HTTPD_BROTLI_QUALITY must be a number between 0 and 11: "best"`)))
+			})
+		})
+
+		context("when $BP_PHP_HTTPD_SERVE_PRECOMPRESSED cannot be parsed into a bool", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_SERVE_PRECOMPRESSED", "blah")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_SERVE_PRECOMPRESSED")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse $BP_PHP_HTTPD_SERVE_PRECOMPRESSED into boolean")))
+			})
+		})
+	})
+}
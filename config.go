@@ -8,7 +8,9 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
 	"text/template"
 
 	"github.com/paketo-buildpacks/packit/v2/scribe"
@@ -23,7 +25,65 @@ type HttpdConfig struct {
 	AppRoot              string
 	WebDirectory         string
 	FpmSocket            string
+	FpmSocketFromFpm     bool
 	UserInclude          string
+	Profile              string
+	ProfileInclude       string
+	IncludeHooks         IncludeHooks
+	Snippets             []string
+	Modules              []string
+	ResponseHeaders      []ResponseHeader
+	HealthCheck          HealthCheck
+	Status               Status
+	CachePolicies        []CachePolicy
+	Compression          Compression
+	ErrorPages           ErrorPages
+	Maintenance          Maintenance
+	CORS                 CORS
+	AccessRules          []AccessRule
+	Limits               Limits
+	VirtualHosts         []VirtualHost
+	Mounts               []Mount
+	NoPHPPaths           []string
+	SensitiveFiles       SensitiveFiles
+	HiddenFiles          HiddenFiles
+	AllowOverride        string
+	HtaccessFiles        []HtaccessFile
+}
+
+// DocumentRoot returns the absolute path of the web directory.
+func (h HttpdConfig) DocumentRoot() string {
+	return filepath.Join(h.AppRoot, h.WebDirectory)
+}
+
+// ExemptPaths lists the request paths that are never redirected to HTTPS or
+// put into maintenance, so that probes and scrapers keep working.
+func (h HttpdConfig) ExemptPaths() []string {
+	var paths []string
+	if h.HealthCheck.Path != "" {
+		paths = append(paths, h.HealthCheck.Path)
+	}
+
+	if h.Status.Enabled && h.Status.Port == 0 {
+		paths = append(paths, h.Status.Path)
+		if h.Status.FpmStatusPath != "" {
+			paths = append(paths, h.Status.FpmStatusPath)
+		}
+	}
+
+	return paths
+}
+
+// templateFuncs are the functions available to the HTTPD configuration
+// template and to user-provided configuration templates.
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"quoteMeta": regexp.QuoteMeta,
+		"join":      strings.Join,
+		"fcgiURL":   fcgiURL,
+		"quote":     quote,
+		"env":       os.Getenv,
+	}
 }
 
 type Config struct {
@@ -36,21 +96,36 @@ func NewConfig(logger scribe.Emitter) Config {
 	}
 }
 
-func (c Config) Write(layerPath, workingDir string) (string, error) {
-	tmpl, err := template.New("httpd.conf").Parse(DefaultHTTPDConfTemplate)
+func (c Config) Write(layerPath, workingDir string) (Metadata, error) {
+	tmpl, err := template.New("httpd.conf").Funcs(templateFuncs()).Parse(DefaultHTTPDConfTemplate)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse HTTPD config template: %w", err)
+		return Metadata{}, fmt.Errorf("failed to parse HTTPD config template: %w", err)
 	}
 
 	// Configuration set by this buildpack
 
+	err = validateAppRoot(workingDir)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	profile, err := loadProfile()
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	file, values, err := readConfigFiles(workingDir, profile)
+	if err != nil {
+		return Metadata{}, err
+	}
+
 	// If there's a user-provided HTTPD conf, include it in the base configuration.
 	userPath := filepath.Join(workingDir, ".httpd.conf.d", "*.conf")
 	_, err = os.Stat(filepath.Join(workingDir, ".httpd.conf.d"))
 	if err != nil {
 		if !errors.Is(err, os.ErrNotExist) {
 			// untested
-			return "", fmt.Errorf("failed to stat %s/.httpd.conf.d: %w", workingDir, err)
+			return Metadata{}, fmt.Errorf("failed to stat %s/.httpd.conf.d: %w", workingDir, err)
 		}
 		userPath = ""
 	}
@@ -58,48 +133,280 @@ func (c Config) Write(layerPath, workingDir string) (string, error) {
 		c.logger.Debug.Subprocess(fmt.Sprintf("Including user-provided HTTPD configuration from: %s", userPath))
 	}
 
-	serverAdmin := os.Getenv("BP_PHP_SERVER_ADMIN")
+	profileInclude, err := c.loadProfileInclude(values, workingDir, profile)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	includeHooks, err := loadIncludeHooks(workingDir)
+	if err != nil {
+		return Metadata{}, err
+	}
+	for _, glob := range []string{includeHooks.Pre, includeHooks.Directory, includeHooks.Post} {
+		if glob != "" {
+			c.logger.Debug.Subprocess(fmt.Sprintf("Including user-provided HTTPD configuration from: %s", glob))
+		}
+	}
+
+	serverAdmin := values.get("BP_PHP_SERVER_ADMIN")
 	if serverAdmin == "" {
 		serverAdmin = "admin@localhost"
 	}
+	err = validateServerAdmin(values, serverAdmin)
+	if err != nil {
+		return Metadata{}, err
+	}
 	c.logger.Debug.Subprocess(fmt.Sprintf("Server admin: %s", serverAdmin))
 
-	webDir := os.Getenv("BP_PHP_WEB_DIR")
-	if webDir == "" {
-		webDir = "htdocs"
+	webDir, err := c.loadWebDirectory(values, workingDir)
+	if err != nil {
+		return Metadata{}, err
 	}
 	c.logger.Debug.Subprocess(fmt.Sprintf("Web directory: %s", webDir))
 
 	enableHTTPSRedirect := true
-	enableHTTPSRedirectStr, ok := os.LookupEnv("BP_PHP_ENABLE_HTTPS_REDIRECT")
+	enableHTTPSRedirectStr, ok := values.lookup("BP_PHP_ENABLE_HTTPS_REDIRECT")
 	if ok {
 		enableHTTPSRedirect, err = strconv.ParseBool(enableHTTPSRedirectStr)
 		if err != nil {
-			return "", fmt.Errorf("failed to pase $BP_PHP_ENABLE_HTTPS_REDIRECT into boolean: %w", err)
+			return Metadata{}, fmt.Errorf("failed to pase %s into boolean: %w", values.describe("BP_PHP_ENABLE_HTTPS_REDIRECT"), err)
 		}
 	}
 	c.logger.Debug.Subprocess(fmt.Sprintf("Enable HTTPS redirect: %t", enableHTTPSRedirect))
-	fpmSocket := "127.0.0.1:9000"
+
+	fpmSocket, fpmSocketFromFpm, err := c.loadFpmSocket(values)
+	if err != nil {
+		return Metadata{}, err
+	}
+	c.logger.Debug.Subprocess(fmt.Sprintf("FPM socket: %s", fpmSocket))
+
+	modules, err := loadModules(values)
+	if err != nil {
+		return Metadata{}, err
+	}
+	if len(modules) > 0 {
+		c.logger.Debug.Subprocess(fmt.Sprintf("Additional modules: %s", strings.Join(modules, " ")))
+	}
+
+	responseHeaders, err := loadResponseHeaders(values)
+	if err != nil {
+		return Metadata{}, err
+	}
+	for _, header := range responseHeaders {
+		c.logger.Debug.Subprocess(fmt.Sprintf("Response header: %s: %s", header.Name, header.Value))
+	}
+
+	healthCheck, err := loadHealthCheck(values, layerPath)
+	if err != nil {
+		return Metadata{}, err
+	}
+	if healthCheck.Path != "" {
+		c.logger.Debug.Subprocess(fmt.Sprintf("Health check path: %s", healthCheck.Path))
+	}
+
+	status, err := loadStatus(values)
+	if err != nil {
+		return Metadata{}, err
+	}
+	c.logger.Debug.Subprocess(fmt.Sprintf("Enable status endpoints: %t", status.Enabled))
+
+	cachePolicies, err := loadCachePolicies(values)
+	if err != nil {
+		return Metadata{}, err
+	}
+	for _, policy := range cachePolicies {
+		c.logger.Debug.Subprocess(fmt.Sprintf("Cache policy: <%s \"%s\"> %s", policy.Section(), policy.Pattern(), policy.CacheControl()))
+	}
+
+	compression, err := loadCompression(values)
+	if err != nil {
+		return Metadata{}, err
+	}
+	c.logger.Debug.Subprocess(fmt.Sprintf("Compression types: %s", strings.Join(compression.Types, " ")))
+	c.logger.Debug.Subprocess(fmt.Sprintf("Enable Brotli compression: %t", compression.Brotli))
+	c.logger.Debug.Subprocess(fmt.Sprintf("Serve precompressed files: %t", compression.Precompressed))
+
+	errorPages, err := loadErrorPages(values, layerPath, workingDir, webDir)
+	if err != nil {
+		return Metadata{}, err
+	}
+	for _, page := range errorPages.Pages {
+		c.logger.Debug.Subprocess(fmt.Sprintf("Error page: %d %s", page.Code, page.URL))
+	}
+
+	maintenance, err := loadMaintenance(values, layerPath, workingDir, webDir)
+	if err != nil {
+		return Metadata{}, err
+	}
+	c.logger.Debug.Subprocess(fmt.Sprintf("Enable maintenance mode: %t", maintenance.Enabled))
+
+	noPHPPaths, err := c.loadNoPHPPaths(values, workingDir, webDir)
+	if err != nil {
+		return Metadata{}, err
+	}
+	if len(noPHPPaths) > 0 {
+		c.logger.Debug.Subprocess(fmt.Sprintf("PHP execution denied in: %s", strings.Join(noPHPPaths, " ")))
+	}
+
+	sensitiveFiles, err := loadSensitiveFiles(values)
+	if err != nil {
+		return Metadata{}, err
+	}
+	c.logger.Debug.Subprocess(fmt.Sprintf("Denied files: %s", strings.Join(sensitiveFiles.Files, " ")))
+	c.logger.Debug.Subprocess(fmt.Sprintf("Denied directories: %s", strings.Join(sensitiveFiles.DeniedDirectories(), " ")))
+
+	err = c.warnExposedFiles(workingDir, webDir, sensitiveFiles)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	hiddenFiles, err := loadHiddenFiles(values, workingDir, webDir)
+	if err != nil {
+		return Metadata{}, err
+	}
+	c.logger.Debug.Subprocess(fmt.Sprintf("Allowed hidden directories: %s", strings.Join(hiddenFiles.Directories, " ")))
+	if len(hiddenFiles.Files) > 0 {
+		c.logger.Debug.Subprocess(fmt.Sprintf("Allowed hidden files: %s", strings.Join(hiddenFiles.Files, " ")))
+	}
+	if hiddenFiles.WellKnownFrontController != "" {
+		c.logger.Debug.Subprocess(fmt.Sprintf("/.well-known front controller: %s", hiddenFiles.WellKnownFrontController))
+	}
+
+	compileHtaccess, err := values.lookupBool("BP_PHP_HTTPD_COMPILE_HTACCESS", false)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	allowOverride, err := loadAllowOverride(values, compileHtaccess)
+	if err != nil {
+		return Metadata{}, err
+	}
+	c.logger.Debug.Subprocess(fmt.Sprintf("AllowOverride: %s", allowOverride))
+
+	cors, err := loadCORS(values)
+	if err != nil {
+		return Metadata{}, err
+	}
+	if cors.Enabled() {
+		c.logger.Debug.Subprocess(fmt.Sprintf("CORS allowed origins: %s", strings.Join(cors.Origins, " ")))
+	}
+
+	accessRules, err := loadAccessRules(values)
+	if err != nil {
+		return Metadata{}, err
+	}
+	for _, rule := range accessRules {
+		c.logger.Debug.Subprocess(fmt.Sprintf("Access control for %s: allow %s, deny %s", rule.Path, strings.Join(rule.Allow, " "), strings.Join(rule.Deny, " ")))
+	}
+
+	limits, err := c.loadLimits(values)
+	if err != nil {
+		return Metadata{}, err
+	}
+	if limits.RequestBody >= 0 {
+		c.logger.Debug.Subprocess(fmt.Sprintf("Request body limit: %d bytes", limits.RequestBody))
+	}
+
+	virtualHosts, err := loadVirtualHosts(file, workingDir, fpmSocket, allowOverride)
+	if err != nil {
+		return Metadata{}, err
+	}
+	for _, host := range virtualHosts {
+		c.logger.Debug.Subprocess(fmt.Sprintf("Virtual host: %s -> %s", host.ServerName, host.WebDirectory))
+	}
+
+	mounts, err := loadMounts(file, workingDir, fpmSocket, allowOverride)
+	if err != nil {
+		return Metadata{}, err
+	}
+	for _, mount := range mounts {
+		c.logger.Debug.Subprocess(fmt.Sprintf("Mount: %s -> %s", mount.Path, mount.WebDirectory))
+	}
+
+	htaccessRoots := htaccessRoots(workingDir, webDir, virtualHosts, mounts)
+
+	var htaccessFiles []HtaccessFile
+	if compileHtaccess {
+		htaccessFiles, err = compileHtaccessFiles(workingDir, htaccessRoots)
+		if err != nil {
+			return Metadata{}, err
+		}
+	}
 
 	data := HttpdConfig{
 		ServerAdmin:          serverAdmin,
 		AppRoot:              workingDir,
 		WebDirectory:         webDir,
 		FpmSocket:            fpmSocket,
+		FpmSocketFromFpm:     fpmSocketFromFpm,
 		DisableHTTPSRedirect: !enableHTTPSRedirect,
 		UserInclude:          userPath,
+		Profile:              profile,
+		ProfileInclude:       profileInclude,
+		IncludeHooks:         includeHooks,
+		Modules:              modules,
+		ResponseHeaders:      responseHeaders,
+		HealthCheck:          healthCheck,
+		Status:               status,
+		CachePolicies:        cachePolicies,
+		Compression:          compression,
+		ErrorPages:           errorPages,
+		Maintenance:          maintenance,
+		CORS:                 cors,
+		AccessRules:          accessRules,
+		Limits:               limits,
+		VirtualHosts:         virtualHosts,
+		Mounts:               mounts,
+		NoPHPPaths:           noPHPPaths,
+		SensitiveFiles:       sensitiveFiles,
+		HiddenFiles:          hiddenFiles,
+		AllowOverride:        allowOverride,
+		HtaccessFiles:        htaccessFiles,
+	}
+
+	err = data.validate()
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	err = c.renderSnippets(values, layerPath, workingDir, &data)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	rows := effectiveSettings(data, values)
+	if changed := changedSettings(rows); len(changed) > 0 {
+		c.logger.Subprocess("Settings changed from their defaults:")
+		for _, line := range formatSettings(changed) {
+			c.logger.Action("%s", line)
+		}
+	}
+	c.logger.Debug.Subprocess("Effective settings:")
+	for _, line := range formatSettings(rows) {
+		c.logger.Debug.Action("%s", line)
 	}
 
 	var b bytes.Buffer
 	err = tmpl.Execute(&b, data)
 	if err != nil {
 		// not tested
-		return "", err
+		return Metadata{}, err
+	}
+
+	metadata := newMetadata(data, filepath.Join(layerPath, "httpd.conf"), b.Bytes())
+
+	err = c.reportHtaccessFiles(workingDir, htaccessRoots, compileHtaccess, metadata.Modules)
+	if err != nil {
+		return Metadata{}, err
+	}
+	err = writeMetadata(filepath.Join(layerPath, MetadataFile), metadata)
+	if err != nil {
+		return Metadata{}, err
 	}
 
 	f, err := os.OpenFile(filepath.Join(layerPath, "httpd.conf"), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.ModePerm)
 	if err != nil {
-		return "", err
+		return Metadata{}, err
 	}
 	defer func() {
 		if err := f.Close(); err != nil {
@@ -110,8 +417,8 @@ func (c Config) Write(layerPath, workingDir string) (string, error) {
 	_, err = io.Copy(f, &b)
 	if err != nil {
 		// not tested
-		return "", err
+		return Metadata{}, err
 	}
 
-	return f.Name(), nil
+	return metadata, nil
 }
@@ -31,6 +31,7 @@ func testConfig(t *testing.T, context spec.G, it spec.S) {
 
 		workingDir, err = os.MkdirTemp("", "workingDir")
 		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
 
 		logEmitter := scribe.NewEmitter(bytes.NewBuffer(nil))
 		config = phphttpd.NewConfig(logEmitter)
@@ -42,10 +43,10 @@ func testConfig(t *testing.T, context spec.G, it spec.S) {
 	})
 
 	it("writes an httpd.conf file into the layer dir", func() {
-		path, err := config.Write(layerDir, workingDir)
+		metadata, err := config.Write(layerDir, workingDir)
 		Expect(err).NotTo(HaveOccurred())
 
-		Expect(path).To(Equal(filepath.Join(layerDir, "httpd.conf")))
+		Expect(metadata.ConfigPath).To(Equal(filepath.Join(layerDir, "httpd.conf")))
 		Expect(filepath.Join(layerDir, "httpd.conf")).To(BeARegularFile())
 
 		contents, err := os.ReadFile(filepath.Join(layerDir, "httpd.conf"))
@@ -55,7 +56,7 @@ func testConfig(t *testing.T, context spec.G, it spec.S) {
 		Expect(string(contents)).To(ContainSubstring(fmt.Sprintf("DocumentRoot \"%s/htdocs\"", workingDir)))
 		Expect(string(contents)).To(ContainSubstring("SetHandler proxy:fcgi://127.0.0.1:9000"))
 		Expect(string(contents)).To(ContainSubstring("RewriteCond %{HTTPS} !=on"))
-		Expect(string(contents)).NotTo(ContainSubstring(fmt.Sprintf("IncludeOptional %s/.httpd.conf.d/*.conf", workingDir)))
+		Expect(string(contents)).NotTo(ContainSubstring(fmt.Sprintf("IncludeOptional \"%s/.httpd.conf.d/*.conf\"", workingDir)))
 	})
 
 	context("there is a user-provided conf file", func() {
@@ -69,22 +70,23 @@ func testConfig(t *testing.T, context spec.G, it spec.S) {
 		})
 
 		it("writes an httpd.conf with the user included conf into layerDir", func() {
-			path, err := config.Write(layerDir, workingDir)
+			metadata, err := config.Write(layerDir, workingDir)
 			Expect(err).NotTo(HaveOccurred())
-			Expect(path).To(Equal(filepath.Join(layerDir, "httpd.conf")))
+			Expect(metadata.ConfigPath).To(Equal(filepath.Join(layerDir, "httpd.conf")))
 			Expect(filepath.Join(layerDir, "httpd.conf")).To(BeARegularFile())
 
 			contents, err := os.ReadFile(filepath.Join(layerDir, "httpd.conf"))
 			Expect(err).NotTo(HaveOccurred())
-			Expect(string(contents)).To(ContainSubstring(fmt.Sprintf("IncludeOptional %s/.httpd.conf.d/*.conf", workingDir)))
+			Expect(string(contents)).To(ContainSubstring(fmt.Sprintf("IncludeOptional \"%s/.httpd.conf.d/*.conf\"", workingDir)))
 		})
 	})
 
 	context("all config env. vars are set", func() {
 		it.Before(func() {
-			Expect(os.Setenv("BP_PHP_SERVER_ADMIN", "some-server-admin")).To(Succeed())
+			Expect(os.Setenv("BP_PHP_SERVER_ADMIN", "admin@example.com")).To(Succeed())
 			Expect(os.Setenv("BP_PHP_ENABLE_HTTPS_REDIRECT", "false")).To(Succeed())
 			Expect(os.Setenv("BP_PHP_WEB_DIR", "some-web-dir")).To(Succeed())
+			Expect(os.Mkdir(filepath.Join(workingDir, "some-web-dir"), os.ModePerm)).To(Succeed())
 		})
 
 		it.After(func() {
@@ -94,13 +96,13 @@ func testConfig(t *testing.T, context spec.G, it spec.S) {
 		})
 
 		it("writes an httpd.conf that includes the env var values", func() {
-			path, err := config.Write(layerDir, workingDir)
+			metadata, err := config.Write(layerDir, workingDir)
 			Expect(err).NotTo(HaveOccurred())
-			Expect(path).To(Equal(filepath.Join(layerDir, "httpd.conf")))
+			Expect(metadata.ConfigPath).To(Equal(filepath.Join(layerDir, "httpd.conf")))
 
 			contents, err := os.ReadFile(filepath.Join(layerDir, "httpd.conf"))
 			Expect(err).NotTo(HaveOccurred())
-			Expect(string(contents)).To(ContainSubstring("ServerAdmin \"some-server-admin\""))
+			Expect(string(contents)).To(ContainSubstring("ServerAdmin \"admin@example.com\""))
 			Expect(string(contents)).To(ContainSubstring(fmt.Sprintf("DocumentRoot \"%s/some-web-dir\"", workingDir)))
 			Expect(string(contents)).NotTo(ContainSubstring("RewriteCond %{HTTPS} !=on"))
 		})
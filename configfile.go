@@ -0,0 +1,420 @@
+package phphttpd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ConfigFile is the name of the structured configuration file, relative to
+// the application root.
+const ConfigFile = ".httpd.toml"
+
+// ProjectFile is the name of the project descriptor, relative to the
+// application root. Its [php.httpd] table accepts the same keys as
+// ConfigFile.
+const ProjectFile = "project.toml"
+
+type configFile struct {
+	ServerAdmin     string                `toml:"server_admin"`
+	WebDirectory    string                `toml:"web_directory"`
+	FpmSocket       string                `toml:"fpm_socket"`
+	VerifyWebDir    bool                  `toml:"verify_web_directory"`
+	RenderTemplates bool                  `toml:"render_templates"`
+	HTTPSRedirect   bool                  `toml:"https_redirect"`
+	Modules         []string              `toml:"modules"`
+	ResponseHeaders map[string]string     `toml:"headers"`
+	HealthCheck     healthCheckSettings   `toml:"health_check"`
+	Status          statusSettings        `toml:"status"`
+	Caching         cachingSettings       `toml:"caching"`
+	Compression     compressionSettings   `toml:"compression"`
+	ErrorPages      errorPagesSettings    `toml:"error_pages"`
+	Maintenance     maintenanceSettings   `toml:"maintenance"`
+	CORS            corsSettings          `toml:"cors"`
+	AccessControl   map[string][]string   `toml:"access_control"`
+	Limits          limitsSettings        `toml:"limits"`
+	PHPExecution    phpExecution          `toml:"php_execution"`
+	SensitiveFiles  sensitiveFiles        `toml:"sensitive_files"`
+	HiddenFiles     hiddenFiles           `toml:"hidden_files"`
+	Htaccess        htaccessSettings      `toml:"htaccess"`
+	Hosts           []VirtualHost         `toml:"hosts"`
+	Mounts          []Mount               `toml:"mounts"`
+	Profiles        map[string]configFile `toml:"profiles"`
+
+	// HostsFile and MountsFile name the file Hosts and Mounts were read from.
+	HostsFile  string `toml:"-"`
+	MountsFile string `toml:"-"`
+}
+
+type healthCheckSettings struct {
+	Path        string `toml:"path"`
+	FpmPingPath string `toml:"fpm_ping_path"`
+	AccessLog   bool   `toml:"access_log"`
+}
+
+type statusSettings struct {
+	Enabled       bool     `toml:"enabled"`
+	Path          string   `toml:"path"`
+	FpmStatusPath string   `toml:"fpm_status_path"`
+	Allow         []string `toml:"allow"`
+	Port          int      `toml:"port"`
+}
+
+type cachingSettings struct {
+	Defaults bool              `toml:"defaults"`
+	Policies map[string]string `toml:"policies"`
+}
+
+type compressionSettings struct {
+	Types         []string `toml:"types"`
+	Level         int      `toml:"level"`
+	Brotli        bool     `toml:"brotli"`
+	BrotliQuality int      `toml:"brotli_quality"`
+	Precompressed bool     `toml:"precompressed"`
+}
+
+type errorPagesSettings struct {
+	Defaults bool              `toml:"defaults"`
+	Pages    map[string]string `toml:"pages"`
+}
+
+type maintenanceSettings struct {
+	Enabled      bool     `toml:"enabled"`
+	File         string   `toml:"file"`
+	Allow        []string `toml:"allow"`
+	BypassHeader string   `toml:"bypass_header"`
+	RetryAfter   int      `toml:"retry_after"`
+	Page         string   `toml:"page"`
+}
+
+type corsSettings struct {
+	AllowedOrigins   []string `toml:"allowed_origins"`
+	AllowedMethods   []string `toml:"allowed_methods"`
+	AllowedHeaders   []string `toml:"allowed_headers"`
+	ExposedHeaders   []string `toml:"exposed_headers"`
+	AllowCredentials bool     `toml:"allow_credentials"`
+	MaxAge           int      `toml:"max_age"`
+}
+
+type limitsSettings struct {
+	RequestBody        string `toml:"request_body"`
+	RequestFields      int    `toml:"request_fields"`
+	RequestFieldSize   int    `toml:"request_field_size"`
+	RequestReadTimeout string `toml:"request_read_timeout"`
+	RateLimit          int    `toml:"rate_limit"`
+}
+
+type phpExecution struct {
+	Deny    []string `toml:"deny"`
+	Presets bool     `toml:"presets"`
+}
+
+type sensitiveFiles struct {
+	Defaults bool     `toml:"defaults"`
+	Deny     []string `toml:"deny"`
+}
+
+type hiddenFiles struct {
+	Allow                    []string `toml:"allow"`
+	WellKnownFrontController string   `toml:"well_known_front_controller"`
+}
+
+type htaccessSettings struct {
+	AllowOverride string `toml:"allow_override"`
+	Compile       bool   `toml:"compile"`
+}
+
+// configFileKeys maps the keys of the configuration file to the environment
+// variables that set the same option.
+var configFileKeys = map[string]string{
+	"server_admin":                             "BP_PHP_SERVER_ADMIN",
+	"web_directory":                            "BP_PHP_WEB_DIR",
+	"https_redirect":                           "BP_PHP_ENABLE_HTTPS_REDIRECT",
+	"fpm_socket":                               "BP_PHP_HTTPD_FPM_SOCKET",
+	"verify_web_directory":                     "BP_PHP_HTTPD_VERIFY_WEB_DIR",
+	"render_templates":                         "BP_PHP_HTTPD_RENDER_TEMPLATES",
+	"modules":                                  "BP_PHP_HTTPD_MODULES",
+	"headers":                                  "BP_PHP_HTTPD_RESPONSE_HEADERS",
+	"health_check.path":                        "BP_PHP_HTTPD_HEALTHCHECK_PATH",
+	"health_check.fpm_ping_path":               "BP_PHP_HTTPD_HEALTHCHECK_FPM_PING_PATH",
+	"health_check.access_log":                  "BP_PHP_HTTPD_HEALTHCHECK_ACCESS_LOG",
+	"status.enabled":                           "BP_PHP_HTTPD_ENABLE_STATUS",
+	"status.path":                              "BP_PHP_HTTPD_STATUS_PATH",
+	"status.fpm_status_path":                   "BP_PHP_HTTPD_FPM_STATUS_PATH",
+	"status.allow":                             "BP_PHP_HTTPD_STATUS_ALLOW",
+	"status.port":                              "BP_PHP_HTTPD_STATUS_PORT",
+	"caching.defaults":                         "BP_PHP_HTTPD_ENABLE_CACHE_DEFAULTS",
+	"caching.policies":                         "BP_PHP_HTTPD_CACHE_POLICIES",
+	"compression.types":                        "BP_PHP_HTTPD_COMPRESSION_TYPES",
+	"compression.level":                        "BP_PHP_HTTPD_COMPRESSION_LEVEL",
+	"compression.brotli":                       "BP_PHP_HTTPD_ENABLE_BROTLI",
+	"compression.brotli_quality":               "BP_PHP_HTTPD_BROTLI_QUALITY",
+	"compression.precompressed":                "BP_PHP_HTTPD_SERVE_PRECOMPRESSED",
+	"error_pages.defaults":                     "BP_PHP_HTTPD_ENABLE_DEFAULT_ERROR_PAGES",
+	"error_pages.pages":                        "BP_PHP_HTTPD_ERROR_PAGES",
+	"maintenance.enabled":                      "BP_PHP_HTTPD_ENABLE_MAINTENANCE_MODE",
+	"maintenance.file":                         "BP_PHP_HTTPD_MAINTENANCE_FILE",
+	"maintenance.allow":                        "BP_PHP_HTTPD_MAINTENANCE_ALLOW",
+	"maintenance.bypass_header":                "BP_PHP_HTTPD_MAINTENANCE_BYPASS_HEADER",
+	"maintenance.retry_after":                  "BP_PHP_HTTPD_MAINTENANCE_RETRY_AFTER",
+	"maintenance.page":                         "BP_PHP_HTTPD_MAINTENANCE_PAGE",
+	"cors.allowed_origins":                     "BP_PHP_HTTPD_CORS_ALLOWED_ORIGINS",
+	"cors.allowed_methods":                     "BP_PHP_HTTPD_CORS_ALLOWED_METHODS",
+	"cors.allowed_headers":                     "BP_PHP_HTTPD_CORS_ALLOWED_HEADERS",
+	"cors.exposed_headers":                     "BP_PHP_HTTPD_CORS_EXPOSED_HEADERS",
+	"cors.allow_credentials":                   "BP_PHP_HTTPD_CORS_ALLOW_CREDENTIALS",
+	"cors.max_age":                             "BP_PHP_HTTPD_CORS_MAX_AGE",
+	"php_execution.deny":                       "BP_PHP_HTTPD_NO_PHP_PATHS",
+	"php_execution.presets":                    "BP_PHP_HTTPD_ENABLE_NO_PHP_PRESETS",
+	"sensitive_files.defaults":                 "BP_PHP_HTTPD_ENABLE_SENSITIVE_FILE_DEFAULTS",
+	"sensitive_files.deny":                     "BP_PHP_HTTPD_DENY_FILES",
+	"hidden_files.allow":                       "BP_PHP_HTTPD_ALLOW_HIDDEN_PATHS",
+	"hidden_files.well_known_front_controller": "BP_PHP_HTTPD_WELL_KNOWN_FRONT_CONTROLLER",
+	"htaccess.allow_override":                  "BP_PHP_HTTPD_ALLOW_OVERRIDE",
+	"htaccess.compile":                         "BP_PHP_HTTPD_COMPILE_HTACCESS",
+	"access_control":                           "BP_PHP_HTTPD_ACCESS_CONTROL",
+	"limits.request_body":                      "BP_PHP_HTTPD_LIMIT_REQUEST_BODY",
+	"limits.request_fields":                    "BP_PHP_HTTPD_LIMIT_REQUEST_FIELDS",
+	"limits.request_field_size":                "BP_PHP_HTTPD_LIMIT_REQUEST_FIELD_SIZE",
+	"limits.request_read_timeout":              "BP_PHP_HTTPD_REQUEST_READ_TIMEOUT",
+	"limits.rate_limit":                        "BP_PHP_HTTPD_RATE_LIMIT",
+}
+
+// readConfigFiles decodes the [php.httpd] table of the application's
+// project.toml and its .httpd.toml, in that order, so that .httpd.toml wins
+// when both declare the same key. Missing files are skipped. When a profile
+// is given, the keys of its [profiles.<name>] table in each file override the
+// ones declared outside of it.
+func readConfigFiles(workingDir, profile string) (configFile, settings, error) {
+	values := settings{}
+
+	project, err := decodeConfigFile(filepath.Join(workingDir, ProjectFile), []string{"php", "httpd"}, profile, values)
+	if err != nil {
+		return configFile{}, nil, err
+	}
+
+	local, err := decodeConfigFile(filepath.Join(workingDir, ConfigFile), nil, profile, values)
+	if err != nil {
+		return configFile{}, nil, err
+	}
+
+	sources := []struct {
+		name    string
+		decoded configFile
+	}{
+		{ProjectFile, project},
+		{ProjectFile, project.Profiles[profile]},
+		{ConfigFile, local},
+		{ConfigFile, local.Profiles[profile]},
+	}
+
+	var file configFile
+	for _, source := range sources {
+		if source.decoded.Hosts != nil {
+			file.Hosts, file.HostsFile = source.decoded.Hosts, source.name
+		}
+		if source.decoded.Mounts != nil {
+			file.Mounts, file.MountsFile = source.decoded.Mounts, source.name
+		}
+	}
+
+	return file, values, nil
+}
+
+// decodeConfigFile decodes the table found at prefix in the given file and
+// records its options in values. Keys the buildpack does not know about are
+// rejected, so that typos do not go unnoticed.
+func decodeConfigFile(path string, prefix []string, profile string, values settings) (configFile, error) {
+	name := filepath.Base(path)
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return configFile{}, nil
+		}
+		// untested
+		return configFile{}, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	var (
+		file configFile
+		raw  map[string]any
+		md   toml.MetaData
+	)
+	if len(prefix) == 0 {
+		md, err = toml.Decode(string(content), &file)
+	} else {
+		var project struct {
+			PHP struct {
+				HTTPD configFile `toml:"httpd"`
+			} `toml:"php"`
+		}
+		md, err = toml.Decode(string(content), &project)
+		file = project.PHP.HTTPD
+	}
+	if err != nil {
+		return configFile{}, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+
+	var unknown []string
+	for _, key := range md.Undecoded() {
+		if hasKeyPrefix(key, prefix) {
+			unknown = append(unknown, fmt.Sprintf("%q on line %d", key.String(), keyLine(string(content), key)))
+		}
+	}
+	if len(unknown) > 0 {
+		return configFile{}, fmt.Errorf("failed to parse %s: unknown key %s", name, strings.Join(unknown, ", "))
+	}
+
+	_, err = toml.Decode(string(content), &raw)
+	if err != nil {
+		// untested
+		return configFile{}, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+
+	tables := [][]string{prefix}
+	if profile != "" {
+		tables = append(tables, append(append([]string{}, prefix...), "profiles", profile))
+	}
+
+	keys := md.Keys()
+	for _, table := range tables {
+		for _, key := range keys {
+			if !hasKeyPrefix(key, table) || len(key) == len(table) {
+				continue
+			}
+
+			env, ok := configFileKeys[strings.Join(key[len(table):], ".")]
+			if !ok {
+				continue
+			}
+
+			values[env] = flattenKey(raw, keys, key, setting{
+				File: name,
+				Key:  strings.Join(key[len(prefix):], "."),
+				Line: keyLine(string(content), key),
+			})
+		}
+	}
+
+	return file, nil
+}
+
+// flattenKey records the value of a key in its environment variable form.
+func flattenKey(raw map[string]any, keys []toml.Key, key toml.Key, value setting) setting {
+	switch v := lookupRaw(raw, key).(type) {
+	case string:
+		value.Value = v
+	case bool:
+		value.Value = strconv.FormatBool(v)
+	case int64:
+		value.Value = strconv.FormatInt(v, 10)
+	case []any:
+		for _, item := range v {
+			value.List = append(value.List, fmt.Sprint(item))
+		}
+	case map[string]any:
+		// Tables keep the order their entries were declared in.
+		for _, child := range keys {
+			if len(child) != len(key)+1 || !hasKeyPrefix(child, key) {
+				continue
+			}
+
+			entry := keyValue{Key: child[len(key)]}
+			switch item := v[entry.Key].(type) {
+			case []any:
+				var items []string
+				for _, i := range item {
+					items = append(items, fmt.Sprint(i))
+				}
+				entry.Value = strings.Join(items, " ")
+			default:
+				entry.Value = fmt.Sprint(item)
+			}
+			value.Pairs = append(value.Pairs, entry)
+		}
+	}
+
+	return value
+}
+
+func hasKeyPrefix(key toml.Key, prefix []string) bool {
+	if len(key) < len(prefix) {
+		return false
+	}
+
+	for i := range prefix {
+		if key[i] != prefix[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func lookupRaw(raw map[string]any, key toml.Key) any {
+	var value any = raw
+	for _, part := range key {
+		table, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = table[part]
+	}
+
+	return value
+}
+
+// keyLine returns the line a key is declared on, or the line of the closest
+// enclosing table when the key itself cannot be found, as is the case for
+// inline tables. It returns 0 if neither is found.
+func keyLine(content string, key toml.Key) int {
+	for n := len(key); n > 0; n-- {
+		want := strings.Join(key[:n], ".")
+
+		var table string
+		for i, line := range strings.Split(content, "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "[") {
+				end := strings.LastIndex(line, "]")
+				if end < 0 {
+					continue
+				}
+				table = normalizeKey(strings.Trim(line[:end], "[]"))
+				if table == want {
+					return i + 1
+				}
+				continue
+			}
+
+			name, _, ok := strings.Cut(line, "=")
+			if !ok || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			full := normalizeKey(name)
+			if table != "" {
+				full = table + "." + full
+			}
+			if full == want {
+				return i + 1
+			}
+		}
+	}
+
+	return 0
+}
+
+// normalizeKey strips the quotes and whitespace from a dotted TOML key.
+func normalizeKey(key string) string {
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,222 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testConfigFile(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "public"), os.ModePerm)).To(Succeed())
+
+		config = phphttpd.NewConfig(scribe.NewEmitter(bytes.NewBuffer(nil)))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	context("when .httpd.toml declares settings", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.toml"), []byte(`
+server_admin = "ops@example.com"
+web_directory = "public"
+https_redirect = false
+
+[compression]
+types = ["text/html", "application/json"]
+level = 9
+
+[caching.policies]
+"/build/" = "1y immutable"
+"png|jpg" = "1h"
+
+[access_control]
+"/admin" = ["10.0.0.0/8", "!10.1.0.0/16"]
+`), 0644)).To(Succeed())
+		})
+
+		it("applies them", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`ServerAdmin "ops@example.com"`))
+			Expect(string(contents)).To(ContainSubstring(`DocumentRoot "` + workingDir + `/public"`))
+			Expect(string(contents)).NotTo(ContainSubstring("If not HTTPS, forward to HTTPS"))
+			Expect(string(contents)).To(ContainSubstring("AddOutputFilterByType DEFLATE text/html application/json"))
+			Expect(string(contents)).To(ContainSubstring("DeflateCompressionLevel 9"))
+			Expect(string(contents)).To(ContainSubstring(`<LocationMatch "^/build/">
+    ExpiresDefault "access plus 31536000 seconds"
+    Header set Cache-Control "public, max-age=31536000, immutable"
+</LocationMatch>
+
+<FilesMatch "\.(?i:png|jpg)$">`))
+			Expect(string(contents)).To(ContainSubstring(`<Location "/admin">
+    <RequireAll>
+        Require ip 10.0.0.0/8
+        Require not ip 10.1.0.0/16
+    </RequireAll>
+</Location>`))
+		})
+
+		context("when the same settings are set in the environment", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_WEB_DIR", "htdocs")).To(Succeed())
+				Expect(os.Setenv("BP_PHP_HTTPD_COMPRESSION_LEVEL", "4")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_WEB_DIR")).To(Succeed())
+				Expect(os.Unsetenv("BP_PHP_HTTPD_COMPRESSION_LEVEL")).To(Succeed())
+			})
+
+			it("gives the environment precedence", func() {
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring(`DocumentRoot "` + workingDir + `/htdocs"`))
+				Expect(string(contents)).To(ContainSubstring("DeflateCompressionLevel 4"))
+				Expect(string(contents)).To(ContainSubstring(`ServerAdmin "ops@example.com"`))
+			})
+		})
+	})
+
+	context("when project.toml has a [php.httpd] table", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "project.toml"), []byte(`
+[_]
+schema-version = "0.2"
+
+[io.buildpacks]
+exclude = ["tests"]
+
+[php.httpd]
+web_directory = "public"
+
+[php.httpd.compression]
+level = 2
+`), 0644)).To(Succeed())
+		})
+
+		it("applies it", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`DocumentRoot "` + workingDir + `/public"`))
+			Expect(string(contents)).To(ContainSubstring("DeflateCompressionLevel 2"))
+		})
+
+		context("when .httpd.toml declares the same settings", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.toml"), []byte(`
+[compression]
+level = 7
+`), 0644)).To(Succeed())
+			})
+
+			it("gives .httpd.toml precedence", func() {
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring(`DocumentRoot "` + workingDir + `/public"`))
+				Expect(string(contents)).To(ContainSubstring("DeflateCompressionLevel 7"))
+			})
+		})
+	})
+
+	context("failure cases", func() {
+		context("when .httpd.toml contains an unknown key", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.toml"), []byte(`
+web_directory = "public"
+
+[compression]
+levle = 9
+`), 0644)).To(Succeed())
+			})
+
+			it("returns an error with the line number", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(`failed to parse .httpd.toml: unknown key "compression.levle" on line 5`))
+			})
+		})
+
+		context("when the [php.httpd] table of project.toml contains an unknown key", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "project.toml"), []byte(`
+[io.buildpacks]
+exclude = ["tests"]
+
+[php.httpd]
+web_dir = "public"
+`), 0644)).To(Succeed())
+			})
+
+			it("returns an error with the line number", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(`failed to parse project.toml: unknown key "php.httpd.web_dir" on line 6`))
+			})
+		})
+
+		context("when a value has the wrong type", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.toml"), []byte(`
+[compression]
+level = "high"
+`), 0644)).To(Succeed())
+			})
+
+			it("returns an error with the line number", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse .httpd.toml: toml: line 3")))
+				Expect(err).To(MatchError(ContainSubstring("incompatible types")))
+			})
+		})
+
+		context("when a value is invalid", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.toml"), []byte(`
+[compression]
+level = 12
+`), 0644)).To(Succeed())
+			})
+
+			it("names the key and line in the error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(`compression.level (.httpd.toml, line 3) must be a number between 1 and 9: "12"`))
+			})
+		})
+	})
+}
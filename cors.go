@@ -0,0 +1,139 @@
+package phphttpd
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	defaultCORSMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	defaultCORSHeaders = []string{"Accept", "Authorization", "Content-Type", "X-Requested-With"}
+)
+
+// CORS configures Access-Control-* response headers for the allowed origins.
+// Preflight requests are answered by HTTPD and never reach php-fpm.
+type CORS struct {
+	Origins          []string
+	Methods          []string
+	Headers          []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           int
+}
+
+// Enabled reports whether any origin is allowed.
+func (c CORS) Enabled() bool {
+	return len(c.Origins) > 0
+}
+
+// AllowAnyOrigin reports whether requests from every origin are allowed.
+func (c CORS) AllowAnyOrigin() bool {
+	for _, origin := range c.Origins {
+		if origin == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// OriginPattern returns a regular expression that matches the Origin header
+// of allowed requests. Exact origins are quoted, and origins prefixed with ~
+// are used as regular expressions.
+func (c CORS) OriginPattern() string {
+	if c.AllowAnyOrigin() {
+		return ".+"
+	}
+
+	var alternatives []string
+	for _, origin := range c.Origins {
+		if pattern, ok := strings.CutPrefix(origin, "~"); ok {
+			alternatives = append(alternatives, pattern)
+			continue
+		}
+		alternatives = append(alternatives, regexp.QuoteMeta(origin))
+	}
+
+	return fmt.Sprintf("^(?:%s)$", strings.Join(alternatives, "|"))
+}
+
+func loadCORS(s settings) (CORS, error) {
+	cors := CORS{
+		Origins:        s.lookupList("BP_PHP_HTTPD_CORS_ALLOWED_ORIGINS"),
+		Methods:        s.lookupList("BP_PHP_HTTPD_CORS_ALLOWED_METHODS"),
+		Headers:        s.lookupList("BP_PHP_HTTPD_CORS_ALLOWED_HEADERS"),
+		ExposedHeaders: s.lookupList("BP_PHP_HTTPD_CORS_EXPOSED_HEADERS"),
+	}
+
+	if !cors.Enabled() {
+		return CORS{}, nil
+	}
+
+	for _, origin := range cors.Origins {
+		err := validateOrigin(origin)
+		if err != nil {
+			return CORS{}, fmt.Errorf("%s contains an invalid origin: %w", s.describe("BP_PHP_HTTPD_CORS_ALLOWED_ORIGINS"), err)
+		}
+	}
+
+	if len(cors.Methods) == 0 {
+		cors.Methods = defaultCORSMethods
+	}
+	for _, method := range cors.Methods {
+		if !isHeaderName(method) {
+			return CORS{}, fmt.Errorf("%s contains an invalid method: %q", s.describe("BP_PHP_HTTPD_CORS_ALLOWED_METHODS"), method)
+		}
+	}
+
+	if len(cors.Headers) == 0 {
+		cors.Headers = defaultCORSHeaders
+	}
+	for _, header := range append(cors.Headers, cors.ExposedHeaders...) {
+		if !isHeaderName(header) && header != "*" {
+			return CORS{}, fmt.Errorf("invalid CORS header name: %q", header)
+		}
+	}
+
+	var err error
+	cors.AllowCredentials, err = s.lookupBool("BP_PHP_HTTPD_CORS_ALLOW_CREDENTIALS", false)
+	if err != nil {
+		return CORS{}, err
+	}
+
+	if cors.AllowCredentials && cors.AllowAnyOrigin() {
+		return CORS{}, fmt.Errorf("%s cannot be used when any origin ('*') is allowed", s.describe("BP_PHP_HTTPD_CORS_ALLOW_CREDENTIALS"))
+	}
+
+	cors.MaxAge, err = s.lookupInt("BP_PHP_HTTPD_CORS_MAX_AGE", 0, 0, 1<<31-1)
+	if err != nil {
+		return CORS{}, err
+	}
+
+	return cors, nil
+}
+
+func validateOrigin(origin string) error {
+	if origin == "*" {
+		return nil
+	}
+
+	if pattern, ok := strings.CutPrefix(origin, "~"); ok {
+		if strings.ContainsAny(pattern, "\"\n") {
+			return fmt.Errorf("%q must not contain quotes or newlines", origin)
+		}
+
+		_, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("%q is not a valid regular expression: %w", origin, err)
+		}
+		return nil
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return fmt.Errorf("%q must be of the form scheme://host[:port]", origin)
+	}
+
+	return nil
+}
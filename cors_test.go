@@ -0,0 +1,209 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testCORS(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+
+		config = phphttpd.NewConfig(scribe.NewEmitter(bytes.NewBuffer(nil)))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("does not set CORS headers by default", func() {
+		metadata, err := config.Write(layerDir, workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(metadata.ConfigPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).NotTo(ContainSubstring("Access-Control"))
+	})
+
+	context("when $BP_PHP_HTTPD_CORS_ALLOWED_ORIGINS is set", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_CORS_ALLOWED_ORIGINS", `https://example.com, ~https://[a-z]+\.example\.org`)).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_CORS_ALLOWED_ORIGINS")).To(Succeed())
+		})
+
+		it("reflects allowed origins and answers preflight requests with the defaults", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`SetEnvIfNoCase Origin "^(?:https://example\.com|https://[a-z]+\.example\.org)$" CORS_ORIGIN=$0
+Header always set Access-Control-Allow-Origin "%{CORS_ORIGIN}e" env=CORS_ORIGIN
+Header always merge Vary Origin
+`))
+			Expect(string(contents)).To(ContainSubstring(`Header always set Access-Control-Allow-Methods "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS" "expr=-n reqenv('CORS_ORIGIN') && %{REQUEST_METHOD} == 'OPTIONS'"`))
+			Expect(string(contents)).To(ContainSubstring(`Header always set Access-Control-Allow-Headers "Accept, Authorization, Content-Type, X-Requested-With" "expr=-n reqenv('CORS_ORIGIN') && %{REQUEST_METHOD} == 'OPTIONS'"`))
+			Expect(string(contents)).To(ContainSubstring(`RewriteCond %{REQUEST_METHOD} =OPTIONS
+RewriteCond %{HTTP:Access-Control-Request-Method} !=""
+RewriteCond %{ENV:CORS_ORIGIN} !=""
+RewriteRule ^ - [R=204,L]`))
+			Expect(string(contents)).NotTo(ContainSubstring("Access-Control-Allow-Credentials"))
+			Expect(string(contents)).NotTo(ContainSubstring("Access-Control-Max-Age"))
+			Expect(string(contents)).NotTo(ContainSubstring("Access-Control-Expose-Headers"))
+		})
+
+		context("when the remaining CORS settings are set", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_CORS_ALLOWED_METHODS", "GET,POST")).To(Succeed())
+				Expect(os.Setenv("BP_PHP_HTTPD_CORS_ALLOWED_HEADERS", "Content-Type,X-Api-Key")).To(Succeed())
+				Expect(os.Setenv("BP_PHP_HTTPD_CORS_EXPOSED_HEADERS", "X-Total-Count")).To(Succeed())
+				Expect(os.Setenv("BP_PHP_HTTPD_CORS_ALLOW_CREDENTIALS", "true")).To(Succeed())
+				Expect(os.Setenv("BP_PHP_HTTPD_CORS_MAX_AGE", "600")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_CORS_ALLOWED_METHODS")).To(Succeed())
+				Expect(os.Unsetenv("BP_PHP_HTTPD_CORS_ALLOWED_HEADERS")).To(Succeed())
+				Expect(os.Unsetenv("BP_PHP_HTTPD_CORS_EXPOSED_HEADERS")).To(Succeed())
+				Expect(os.Unsetenv("BP_PHP_HTTPD_CORS_ALLOW_CREDENTIALS")).To(Succeed())
+				Expect(os.Unsetenv("BP_PHP_HTTPD_CORS_MAX_AGE")).To(Succeed())
+			})
+
+			it("renders them", func() {
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring(`Header always set Access-Control-Allow-Credentials "true" env=CORS_ORIGIN`))
+				Expect(string(contents)).To(ContainSubstring(`Header always set Access-Control-Expose-Headers "X-Total-Count" env=CORS_ORIGIN`))
+				Expect(string(contents)).To(ContainSubstring(`Header always set Access-Control-Allow-Methods "GET, POST"`))
+				Expect(string(contents)).To(ContainSubstring(`Header always set Access-Control-Allow-Headers "Content-Type, X-Api-Key"`))
+				Expect(string(contents)).To(ContainSubstring(`Header always set Access-Control-Max-Age "600"`))
+			})
+		})
+	})
+
+	context("when any origin is allowed", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_CORS_ALLOWED_ORIGINS", "*")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_CORS_ALLOWED_ORIGINS")).To(Succeed())
+		})
+
+		it("sends a wildcard origin", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`SetEnvIfNoCase Origin ".+" CORS_ORIGIN=$0`))
+			Expect(string(contents)).To(ContainSubstring(`Header always set Access-Control-Allow-Origin "*" env=CORS_ORIGIN`))
+			Expect(string(contents)).NotTo(ContainSubstring("Vary Origin"))
+		})
+	})
+
+	context("failure cases", func() {
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_CORS_ALLOWED_ORIGINS")).To(Succeed())
+		})
+
+		context("when an exact origin is malformed", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_CORS_ALLOWED_ORIGINS", "https://example.com/path")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`"https://example.com/path" must be of the form scheme://host[:port]`)))
+			})
+		})
+
+		context("when an origin pattern is not a regular expression", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_CORS_ALLOWED_ORIGINS", "~https://(example.com")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`"~https://(example.com" is not a valid regular expression`)))
+			})
+		})
+
+		context("when a method is invalid", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_CORS_ALLOWED_ORIGINS", "https://example.com")).To(Succeed())
+				Expect(os.Setenv("BP_PHP_HTTPD_CORS_ALLOWED_METHODS", `GET,"POST"`)).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_CORS_ALLOWED_METHODS")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`$BP_PHP_HTTPD_CORS_ALLOWED_METHODS contains an invalid method: "\"POST\""`)))
+			})
+		})
+
+		context("when credentials are allowed for any origin", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_CORS_ALLOWED_ORIGINS", "*")).To(Succeed())
+				Expect(os.Setenv("BP_PHP_HTTPD_CORS_ALLOW_CREDENTIALS", "true")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_CORS_ALLOW_CREDENTIALS")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("$BP_PHP_HTTPD_CORS_ALLOW_CREDENTIALS cannot be used when any origin ('*') is allowed")))
+			})
+		})
+
+		context("when the max age is invalid", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_CORS_ALLOWED_ORIGINS", "https://example.com")).To(Succeed())
+				Expect(os.Setenv("BP_PHP_HTTPD_CORS_MAX_AGE", "-1")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_CORS_MAX_AGE")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`$BP_PHP_HTTPD_CORS_MAX_AGE must be a number`)))
+			})
+		})
+	})
+}
@@ -0,0 +1,257 @@
+package phphttpd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Setting is an effective configuration value together with where it came
+// from: "default", an environment variable, a configuration file and line,
+// "preset" for values supplied by a built-in preset, or the file a value was
+// derived from.
+type Setting struct {
+	Name   string
+	Value  string
+	Source string
+}
+
+// source returns where the value of the given setting comes from.
+func (s settings) source(name string) string {
+	if _, ok := os.LookupEnv(name); ok {
+		return "$" + name
+	}
+
+	if value, ok := s[name]; ok {
+		if value.Line > 0 {
+			return fmt.Sprintf("%s:%d", value.File, value.Line)
+		}
+		return value.File
+	}
+
+	return "default"
+}
+
+// isSet reports whether the given setting is set in the environment or a
+// configuration file.
+func (s settings) isSet(name string) bool {
+	_, ok := s.lookup(name)
+	return ok
+}
+
+// effectiveSettings lists every option of the rendered configuration, named
+// by its configuration file key.
+func effectiveSettings(data HttpdConfig, s settings) []Setting {
+	var rows []Setting
+	add := func(key, value string) {
+		rows = append(rows, Setting{Name: key, Value: orNone(value), Source: s.source(configFileKeys[key])})
+	}
+	// flag reports options that only affect how other values are derived.
+	// They have already been validated while loading.
+	flag := func(key string, def bool) string {
+		value, _ := s.lookupBool(configFileKeys[key], def)
+		return strconv.FormatBool(value)
+	}
+	list := func(values []string) string {
+		return strings.Join(values, " ")
+	}
+
+	add("server_admin", data.ServerAdmin)
+	add("web_directory", data.WebDirectory)
+	if data.WebDirectory != defaultWebDirectory && !s.isSet(configFileKeys["web_directory"]) {
+		rows[len(rows)-1].Source = "inferred"
+	}
+	add("verify_web_directory", flag("verify_web_directory", true))
+	add("https_redirect", strconv.FormatBool(!data.DisableHTTPSRedirect))
+	add("fpm_socket", data.FpmSocket)
+	if data.FpmSocketFromFpm {
+		rows[len(rows)-1].Source = "$PHP_FPM_PATH"
+	}
+	add("modules", list(data.Modules))
+
+	var headers []string
+	for _, header := range data.ResponseHeaders {
+		headers = append(headers, fmt.Sprintf("%s: %s", header.Name, header.Value))
+	}
+	add("headers", strings.Join(headers, "; "))
+
+	add("health_check.path", data.HealthCheck.Path)
+	add("health_check.fpm_ping_path", data.HealthCheck.FpmPingPath)
+	add("health_check.access_log", flag("health_check.access_log", true))
+
+	add("status.enabled", strconv.FormatBool(data.Status.Enabled))
+	add("status.path", data.Status.Path)
+	add("status.fpm_status_path", data.Status.FpmStatusPath)
+	add("status.allow", list(data.Status.Allow))
+	add("status.port", formatPort(data.Status.Port))
+
+	add("caching.defaults", flag("caching.defaults", false))
+	var policies []string
+	for _, policy := range data.CachePolicies {
+		policies = append(policies, fmt.Sprintf("%s=%s", policy.Pattern(), policy.CacheControl()))
+	}
+	add("caching.policies", strings.Join(policies, ", "))
+	if len(policies) > 0 && !s.isSet(configFileKeys["caching.policies"]) {
+		rows[len(rows)-1].Source = "preset"
+	}
+
+	add("compression.types", list(data.Compression.Types))
+	add("compression.level", strconv.Itoa(data.Compression.Level))
+	add("compression.brotli", strconv.FormatBool(data.Compression.Brotli))
+	add("compression.brotli_quality", strconv.Itoa(data.Compression.BrotliQuality))
+	add("compression.precompressed", strconv.FormatBool(data.Compression.Precompressed))
+
+	var pages []string
+	for _, page := range data.ErrorPages.Pages {
+		pages = append(pages, fmt.Sprintf("%d=%s", page.Code, page.URL))
+	}
+	add("error_pages.defaults", flag("error_pages.defaults", false))
+	add("error_pages.pages", strings.Join(pages, ", "))
+	if len(pages) > 0 && !s.isSet(configFileKeys["error_pages.pages"]) {
+		rows[len(rows)-1].Source = ErrorPagesDirectory
+	}
+
+	add("maintenance.enabled", strconv.FormatBool(data.Maintenance.Enabled))
+	add("maintenance.file", data.Maintenance.FlagFile)
+	add("maintenance.allow", list(data.Maintenance.Allow))
+	if data.Maintenance.BypassHeader != "" {
+		add("maintenance.bypass_header", fmt.Sprintf("%s=%s", data.Maintenance.BypassHeader, data.Maintenance.BypassValue))
+	} else {
+		add("maintenance.bypass_header", "")
+	}
+	retryAfter, _ := s.lookupInt(configFileKeys["maintenance.retry_after"], 300, 0, 1<<31-1)
+	add("maintenance.retry_after", strconv.Itoa(retryAfter))
+	add("maintenance.page", s.get(configFileKeys["maintenance.page"]))
+
+	add("php_execution.presets", flag("php_execution.presets", true))
+	add("php_execution.deny", list(data.NoPHPPaths))
+	if len(data.NoPHPPaths) > 0 && !s.isSet(configFileKeys["php_execution.deny"]) {
+		rows[len(rows)-1].Source = "preset"
+	}
+
+	add("sensitive_files.defaults", flag("sensitive_files.defaults", true))
+	var denied []string
+	denied = append(denied, data.SensitiveFiles.Files...)
+	for _, directory := range data.SensitiveFiles.DeniedDirectories() {
+		denied = append(denied, directory+"/")
+	}
+	add("sensitive_files.deny", list(denied))
+	if len(denied) > 0 && !s.isSet(configFileKeys["sensitive_files.deny"]) {
+		rows[len(rows)-1].Source = "preset"
+	}
+
+	var allowed []string
+	for _, directory := range data.HiddenFiles.Directories {
+		allowed = append(allowed, directory+"/")
+	}
+	allowed = append(allowed, data.HiddenFiles.Files...)
+	add("hidden_files.allow", list(allowed))
+	if !s.isSet(configFileKeys["hidden_files.allow"]) {
+		rows[len(rows)-1].Source = "preset"
+	}
+	add("hidden_files.well_known_front_controller", data.HiddenFiles.WellKnownFrontController)
+
+	add("htaccess.allow_override", data.AllowOverride)
+	add("htaccess.compile", flag("htaccess.compile", false))
+
+	add("cors.allowed_origins", list(data.CORS.Origins))
+	add("cors.allowed_methods", list(data.CORS.Methods))
+	add("cors.allowed_headers", list(data.CORS.Headers))
+	add("cors.exposed_headers", list(data.CORS.ExposedHeaders))
+	add("cors.allow_credentials", strconv.FormatBool(data.CORS.AllowCredentials))
+	add("cors.max_age", strconv.Itoa(data.CORS.MaxAge))
+
+	var rules []string
+	for _, rule := range data.AccessRules {
+		addresses := rule.Allow
+		for _, deny := range rule.Deny {
+			addresses = append(addresses, "!"+deny)
+		}
+		rules = append(rules, fmt.Sprintf("%s=%s", rule.Path, list(addresses)))
+	}
+	add("access_control", strings.Join(rules, ", "))
+
+	if data.Limits.RequestBody >= 0 {
+		add("limits.request_body", strconv.FormatInt(data.Limits.RequestBody, 10))
+		if !s.isSet(configFileKeys["limits.request_body"]) {
+			rows[len(rows)-1].Source = "php.ini"
+		}
+	} else {
+		add("limits.request_body", "")
+	}
+	add("limits.request_fields", strconv.Itoa(data.Limits.RequestFields))
+	add("limits.request_field_size", strconv.Itoa(data.Limits.RequestFieldSize))
+	add("limits.request_read_timeout", data.Limits.RequestReadTimeout)
+	add("limits.rate_limit", strconv.Itoa(data.Limits.RateLimit))
+
+	var hosts []string
+	for _, host := range data.VirtualHosts {
+		hosts = append(hosts, fmt.Sprintf("%s=%s", host.ServerName, host.WebDirectory))
+	}
+	var mounts []string
+	for _, mount := range data.Mounts {
+		mounts = append(mounts, fmt.Sprintf("%s=%s", mount.Path, mount.WebDirectory))
+	}
+	rows = append(rows,
+		Setting{Name: "hosts", Value: orNone(strings.Join(hosts, ", ")), Source: sourceIf(len(hosts) > 0, ConfigFile)},
+		Setting{Name: "mounts", Value: orNone(strings.Join(mounts, ", ")), Source: sourceIf(len(mounts) > 0, ConfigFile)},
+		Setting{Name: "user_include", Value: orNone(data.UserInclude), Source: sourceIf(data.UserInclude != "", ".httpd.conf.d")},
+		Setting{Name: "profile", Value: orNone(data.Profile), Source: sourceIf(data.Profile != "", "$BP_PHP_HTTPD_PROFILE")},
+		Setting{Name: "profile_include", Value: orNone(data.ProfileInclude), Source: sourceIf(data.ProfileInclude != "", ".httpd.conf.d/profiles/"+data.Profile)},
+		Setting{Name: "render_templates", Value: flag("render_templates", false), Source: s.source(configFileKeys["render_templates"])},
+		Setting{Name: "include_hooks.pre", Value: orNone(data.IncludeHooks.Pre), Source: sourceIf(data.IncludeHooks.Pre != "", ".httpd.conf.d/pre")},
+		Setting{Name: "include_hooks.directory", Value: orNone(data.IncludeHooks.Directory), Source: sourceIf(data.IncludeHooks.Directory != "", ".httpd.conf.d/directory")},
+		Setting{Name: "include_hooks.post", Value: orNone(data.IncludeHooks.Post), Source: sourceIf(data.IncludeHooks.Post != "", ".httpd.conf.d/post")},
+	)
+
+	return rows
+}
+
+// changedSettings returns the settings that were set by the user, leaving out
+// default values and those supplied by a built-in preset.
+func changedSettings(rows []Setting) []Setting {
+	var changed []Setting
+	for _, row := range rows {
+		if row.Source != "default" && row.Source != "preset" {
+			changed = append(changed, row)
+		}
+	}
+	return changed
+}
+
+// formatSettings renders the settings as an aligned table.
+func formatSettings(rows []Setting) []string {
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Setting\tSource\tValue")
+	for _, row := range rows {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", row.Name, row.Source, row.Value)
+	}
+	_ = w.Flush()
+
+	return strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+}
+
+func formatPort(port int) string {
+	if port == 0 {
+		return ""
+	}
+	return strconv.Itoa(port)
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
+
+func sourceIf(ok bool, source string) string {
+	if ok {
+		return source
+	}
+	return "default"
+}
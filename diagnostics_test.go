@@ -0,0 +1,132 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testDiagnostics(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		buffer     *bytes.Buffer
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "public"), os.ModePerm)).To(Succeed())
+
+		Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.toml"), []byte(`
+[compression]
+level = 8
+`), 0644)).To(Succeed())
+		Expect(os.Setenv("BP_PHP_WEB_DIR", "public")).To(Succeed())
+		Expect(os.Setenv("BP_PHP_HTTPD_ENABLE_CACHE_DEFAULTS", "true")).To(Succeed())
+
+		buffer = bytes.NewBuffer(nil)
+		config = phphttpd.NewConfig(scribe.NewEmitter(buffer))
+	})
+
+	it.After(func() {
+		Expect(os.Unsetenv("BP_PHP_WEB_DIR")).To(Succeed())
+		Expect(os.Unsetenv("BP_PHP_HTTPD_ENABLE_CACHE_DEFAULTS")).To(Succeed())
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("prints the settings changed from their defaults and where they came from", func() {
+		_, err := config.Write(layerDir, workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(buffer.String()).To(ContainSubstring("Settings changed from their defaults:"))
+		Expect(buffer.String()).To(MatchRegexp(`\n      Setting +Source +Value\n`))
+		Expect(buffer.String()).To(MatchRegexp(`\n      web_directory +\$BP_PHP_WEB_DIR +public\n`))
+		Expect(buffer.String()).To(MatchRegexp(`\n      compression.level +\.httpd\.toml:3 +8\n`))
+		Expect(buffer.String()).To(MatchRegexp(`\n      caching.defaults +\$BP_PHP_HTTPD_ENABLE_CACHE_DEFAULTS +true\n`))
+		Expect(buffer.String()).NotTo(ContainSubstring("caching.policies"))
+		Expect(buffer.String()).NotTo(ContainSubstring("server_admin"))
+		Expect(buffer.String()).NotTo(ContainSubstring("Effective settings:"))
+	})
+
+	context("when the log level is DEBUG", func() {
+		it.Before(func() {
+			config = phphttpd.NewConfig(scribe.NewEmitter(buffer).WithLevel("DEBUG"))
+		})
+
+		it("prints every effective setting and where it came from", func() {
+			_, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).To(ContainSubstring("Effective settings:"))
+			Expect(buffer.String()).To(MatchRegexp(`\n      server_admin +default +admin@localhost\n`))
+			Expect(buffer.String()).To(MatchRegexp(`\n      web_directory +\$BP_PHP_WEB_DIR +public\n`))
+			Expect(buffer.String()).To(MatchRegexp(`\n      caching.policies +preset +\S+`))
+			Expect(buffer.String()).To(MatchRegexp(`\n      user_include +default +\(none\)\n`))
+		})
+	})
+
+	context("when nothing is changed from its default", func() {
+		it.Before(func() {
+			Expect(os.Unsetenv("BP_PHP_WEB_DIR")).To(Succeed())
+			Expect(os.Unsetenv("BP_PHP_HTTPD_ENABLE_CACHE_DEFAULTS")).To(Succeed())
+			Expect(os.Remove(filepath.Join(workingDir, ".httpd.toml"))).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+		})
+
+		it("does not print the settings", func() {
+			_, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).NotTo(ContainSubstring("Settings changed from their defaults:"))
+		})
+	})
+
+	context("when $PHP_FPM_PATH is set", func() {
+		var fpmConfig string
+
+		it.Before(func() {
+			fpmConfig = filepath.Join(layerDir, "php-fpm.conf")
+			Expect(os.Setenv("PHP_FPM_PATH", fpmConfig)).To(Succeed())
+
+			config = phphttpd.NewConfig(scribe.NewEmitter(buffer).WithLevel("DEBUG"))
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("PHP_FPM_PATH")).To(Succeed())
+		})
+
+		it("names it as the source of the FPM socket", func() {
+			Expect(os.WriteFile(fpmConfig, []byte("[www]\nlisten = 127.0.0.1:9001\n"), 0600)).To(Succeed())
+
+			_, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).To(MatchRegexp(`\n      fpm_socket +\$PHP_FPM_PATH +127\.0\.0\.1:9001\n`))
+		})
+
+		it("does not name it when it has no listen directive", func() {
+			Expect(os.WriteFile(fpmConfig, []byte("[global]\npid = /tmp/php-fpm.pid\n"), 0600)).To(Succeed())
+
+			_, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).To(MatchRegexp(`\n      fpm_socket +default +127\.0\.0\.1:9000\n`))
+		})
+	})
+}
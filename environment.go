@@ -0,0 +1,146 @@
+package phphttpd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// settings holds the values declared in the application's configuration
+// files, keyed by the environment variable that sets the same option. Values
+// from the environment always take precedence over the ones in a file.
+type settings map[string]setting
+
+// setting is a value read from a configuration file. Scalars are kept in
+// their environment variable form in Value, arrays in List and tables in
+// Pairs.
+type setting struct {
+	File  string
+	Key   string
+	Line  int
+	Value string
+	List  []string
+	Pairs []keyValue
+}
+
+// lookup returns the value of the given environment variable, falling back to
+// the configuration files.
+func (s settings) lookup(name string) (string, bool) {
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true
+	}
+
+	if value, ok := s[name]; ok {
+		return value.Value, true
+	}
+
+	return "", false
+}
+
+// get returns the value of the given setting, or the empty string when it is
+// unset.
+func (s settings) get(name string) string {
+	value, _ := s.lookup(name)
+	return value
+}
+
+// describe names the origin of a setting for error messages: the environment
+// variable, or the key and line in the configuration file it was read from.
+func (s settings) describe(name string) string {
+	if _, ok := os.LookupEnv(name); !ok {
+		if value, ok := s[name]; ok {
+			if value.Line > 0 {
+				return fmt.Sprintf("%s (%s, line %d)", value.Key, value.File, value.Line)
+			}
+			return fmt.Sprintf("%s (%s)", value.Key, value.File)
+		}
+	}
+
+	return "$" + name
+}
+
+// lookupBool returns the boolean value of the given setting, or the provided
+// default when it is unset.
+func (s settings) lookupBool(name string, def bool) (bool, error) {
+	value, ok := s.lookup(name)
+	if !ok {
+		return def, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse %s into boolean: %w", s.describe(name), err)
+	}
+
+	return b, nil
+}
+
+// lookupInt returns the integer value of the given setting, or the provided
+// default when it is unset. Values outside of the inclusive min and max bounds
+// are rejected.
+func (s settings) lookupInt(name string, def, min, max int) (int, error) {
+	value, ok := s.lookup(name)
+	if !ok {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("%s must be a number between %d and %d: %q", s.describe(name), min, max, value)
+	}
+
+	return n, nil
+}
+
+// lookupList returns the entries of the given setting. In the environment
+// they may be separated by commas and/or whitespace.
+func (s settings) lookupList(name string) []string {
+	if value, ok := os.LookupEnv(name); ok {
+		return strings.FieldsFunc(value, func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		})
+	}
+
+	return s[name].List
+}
+
+type keyValue struct {
+	Key   string
+	Value string
+}
+
+// lookupKeyValues returns the key=value entries of the given setting, in the
+// order they were declared. In the environment they are separated by commas.
+func (s settings) lookupKeyValues(name string) ([]keyValue, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return s[name].Pairs, nil
+	}
+
+	var entries []keyValue
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("failed to parse $%s: entry %q is not of the form key=value", name, entry)
+		}
+
+		entries = append(entries, keyValue{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)})
+	}
+
+	return entries, nil
+}
+
+// isIPOrCIDR reports whether the value is an IP address or a CIDR range, as
+// accepted by HTTPD's "Require ip".
+func isIPOrCIDR(value string) bool {
+	_, _, err := net.ParseCIDR(value)
+	return err == nil || net.ParseIP(value) != nil
+}
@@ -0,0 +1,190 @@
+package phphttpd
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed assets/error-pages/unavailable.html
+var defaultUnavailablePage []byte
+
+const (
+	// ErrorPagesDirectory is the directory, relative to the application root,
+	// that holds error pages which should not be served from the web
+	// directory. Files named after a status code, such as 503.html, are used
+	// for that status code automatically.
+	ErrorPagesDirectory = ".httpd-errors"
+
+	// errorPagesURL is the URL path error pages outside of the web directory
+	// are served from.
+	errorPagesURL = "/.httpd-errors/"
+)
+
+// ErrorPage maps an HTTP status code to the local URL of the document served
+// for it.
+type ErrorPage struct {
+	Code int
+	URL  string
+}
+
+// ErrorPages holds the ErrorDocument mappings. Pages outside of the web
+// directory are copied into Directory, in the config layer, and aliased to
+// /.httpd-errors/.
+type ErrorPages struct {
+	Pages     []ErrorPage
+	Directory string
+}
+
+// URLPrefix returns the URL path that Directory is served from.
+func (e ErrorPages) URLPrefix() string {
+	return errorPagesURL
+}
+
+func loadErrorPages(s settings, layerPath, workingDir, webDir string) (ErrorPages, error) {
+	entries, err := s.lookupKeyValues("BP_PHP_HTTPD_ERROR_PAGES")
+	if err != nil {
+		return ErrorPages{}, err
+	}
+
+	files := map[int]string{}
+	for _, entry := range entries {
+		code, err := parseErrorCode(entry.Key)
+		if err != nil {
+			return ErrorPages{}, fmt.Errorf("failed to parse %s: %w", s.describe("BP_PHP_HTTPD_ERROR_PAGES"), err)
+		}
+
+		if entry.Value == "" {
+			return ErrorPages{}, fmt.Errorf("failed to parse %s: missing error page for status %d", s.describe("BP_PHP_HTTPD_ERROR_PAGES"), code)
+		}
+		file, ok := cleanPagePath(entry.Value)
+		if !ok {
+			return ErrorPages{}, fmt.Errorf("failed to parse %s: invalid error page for status %d: %q", s.describe("BP_PHP_HTTPD_ERROR_PAGES"), code, entry.Value)
+		}
+		files[code] = file
+	}
+
+	discovered, err := filepath.Glob(filepath.Join(workingDir, ErrorPagesDirectory, "[45][0-9][0-9].html"))
+	if err != nil {
+		// untested
+		return ErrorPages{}, err
+	}
+	for _, match := range discovered {
+		code, _ := strconv.Atoi(strings.TrimSuffix(filepath.Base(match), ".html"))
+		if _, ok := files[code]; !ok {
+			files[code] = path.Join(ErrorPagesDirectory, filepath.Base(match))
+		}
+	}
+
+	enableDefaults, err := s.lookupBool("BP_PHP_HTTPD_ENABLE_DEFAULT_ERROR_PAGES", false)
+	if err != nil {
+		return ErrorPages{}, err
+	}
+
+	var errorPages ErrorPages
+	directory := filepath.Join(layerPath, "error-pages")
+	for code, file := range files {
+		if !strings.HasPrefix(file, ErrorPagesDirectory+"/") {
+			_, err := os.Stat(filepath.Join(workingDir, pageLocation(webDir, file)))
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					return ErrorPages{}, fmt.Errorf("error page for status %d does not exist: %s", code, pageLocation(webDir, file))
+				}
+				// untested
+				return ErrorPages{}, err
+			}
+
+			errorPages.Pages = append(errorPages.Pages, ErrorPage{Code: code, URL: "/" + file})
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(workingDir, file))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return ErrorPages{}, fmt.Errorf("error page for status %d does not exist: %s", code, file)
+			}
+			// untested
+			return ErrorPages{}, err
+		}
+
+		name := strings.TrimPrefix(file, ErrorPagesDirectory+"/")
+		err = writeErrorPage(directory, name, content)
+		if err != nil {
+			return ErrorPages{}, err
+		}
+
+		errorPages.Directory = directory
+		errorPages.Pages = append(errorPages.Pages, ErrorPage{Code: code, URL: errorPagesURL + name})
+	}
+
+	if enableDefaults {
+		for _, code := range []int{502, 503} {
+			if _, ok := files[code]; ok {
+				continue
+			}
+
+			err = writeErrorPage(directory, "unavailable.html", defaultUnavailablePage)
+			if err != nil {
+				return ErrorPages{}, err
+			}
+
+			errorPages.Directory = directory
+			errorPages.Pages = append(errorPages.Pages, ErrorPage{Code: code, URL: errorPagesURL + "unavailable.html"})
+		}
+	}
+
+	sort.Slice(errorPages.Pages, func(i, j int) bool {
+		return errorPages.Pages[i].Code < errorPages.Pages[j].Code
+	})
+
+	return errorPages, nil
+}
+
+// cleanPagePath normalizes the path of a page given by the user, rejecting
+// paths that leave the application or cannot be used as a local URL.
+func cleanPagePath(value string) (string, bool) {
+	file := filepath.ToSlash(filepath.Clean(strings.TrimPrefix(value, "/")))
+	if file == ".." || strings.HasPrefix(file, "../") || strings.ContainsAny(file, " \t\"") {
+		return "", false
+	}
+	return file, true
+}
+
+// pageLocation returns the location of a cleaned page path relative to the
+// application root. Pages are found in the web directory unless they are in
+// the .httpd-errors directory.
+func pageLocation(webDir, file string) string {
+	if strings.HasPrefix(file, ErrorPagesDirectory+"/") {
+		return file
+	}
+	return filepath.Join(webDir, file)
+}
+
+func parseErrorCode(value string) (int, error) {
+	code, err := strconv.Atoi(value)
+	if err != nil || code < 400 || code > 599 {
+		return 0, fmt.Errorf("%q is not an HTTP error status code", value)
+	}
+	return code, nil
+}
+
+func writeErrorPage(directory, name string, content []byte) error {
+	target := filepath.Join(directory, filepath.FromSlash(name))
+	err := os.MkdirAll(filepath.Dir(target), os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("failed to write error page: %w", err)
+	}
+
+	err = os.WriteFile(target, content, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write error page: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,208 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testErrorPages(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+
+		config = phphttpd.NewConfig(scribe.NewEmitter(bytes.NewBuffer(nil)))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("does not render error documents by default", func() {
+		metadata, err := config.Write(layerDir, workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(metadata.ConfigPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).NotTo(ContainSubstring("ErrorDocument"))
+		Expect(filepath.Join(layerDir, "error-pages")).NotTo(BeAnExistingFile())
+	})
+
+	context("when $BP_PHP_HTTPD_ERROR_PAGES maps to files in the web directory", func() {
+		it.Before(func() {
+			Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs", "errors"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "htdocs", "errors", "404.html"), nil, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "htdocs", "500.html"), nil, 0644)).To(Succeed())
+			Expect(os.Setenv("BP_PHP_HTTPD_ERROR_PAGES", "404=errors/404.html,500=/500.html")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_ERROR_PAGES")).To(Succeed())
+		})
+
+		it("renders ErrorDocument directives for their URLs", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("ErrorDocument 404 /errors/404.html\nErrorDocument 500 /500.html\n"))
+			Expect(string(contents)).NotTo(ContainSubstring("Alias"))
+		})
+	})
+
+	context("when there is a .httpd-errors directory", func() {
+		it.Before(func() {
+			Expect(os.MkdirAll(filepath.Join(workingDir, ".httpd-errors"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, ".httpd-errors", "503.html"), []byte("down"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, ".httpd-errors", "oops.html"), []byte("oops"), 0644)).To(Succeed())
+			Expect(os.Setenv("BP_PHP_HTTPD_ERROR_PAGES", "500=.httpd-errors/oops.html")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_ERROR_PAGES")).To(Succeed())
+		})
+
+		it("copies the pages into the layer and aliases them", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.ReadFile(filepath.Join(layerDir, "error-pages", "503.html"))).To(Equal([]byte("down")))
+			Expect(os.ReadFile(filepath.Join(layerDir, "error-pages", "oops.html"))).To(Equal([]byte("oops")))
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("LoadModule alias_module modules/mod_alias.so"))
+			Expect(string(contents)).To(ContainSubstring(`Alias "/.httpd-errors/" "` + filepath.Join(layerDir, "error-pages") + `/"`))
+			Expect(string(contents)).To(ContainSubstring(`<Location "/.httpd-errors/">
+    Require all granted
+</Location>`))
+			Expect(string(contents)).To(ContainSubstring("ErrorDocument 500 /.httpd-errors/oops.html\nErrorDocument 503 /.httpd-errors/503.html\n"))
+		})
+	})
+
+	context("when $BP_PHP_HTTPD_ENABLE_DEFAULT_ERROR_PAGES is true", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_ENABLE_DEFAULT_ERROR_PAGES", "true")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_ENABLE_DEFAULT_ERROR_PAGES")).To(Succeed())
+		})
+
+		it("uses the built-in page for 502 and 503", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			page, err := os.ReadFile(filepath.Join(layerDir, "error-pages", "unavailable.html"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(page)).To(ContainSubstring("temporarily unable to handle your request"))
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("ErrorDocument 502 /.httpd-errors/unavailable.html\nErrorDocument 503 /.httpd-errors/unavailable.html\n"))
+		})
+
+		context("and the app provides its own 503 page", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(workingDir, ".httpd-errors"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, ".httpd-errors", "503.html"), nil, 0644)).To(Succeed())
+			})
+
+			it("prefers the app page", func() {
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring("ErrorDocument 502 /.httpd-errors/unavailable.html\nErrorDocument 503 /.httpd-errors/503.html\n"))
+			})
+		})
+	})
+
+	context("failure cases", func() {
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_ERROR_PAGES")).To(Succeed())
+		})
+
+		context("when the status code is not an error code", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_ERROR_PAGES", "200=ok.html")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`"200" is not an HTTP error status code`)))
+			})
+		})
+
+		context("when the error page is outside of the app", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_ERROR_PAGES", "404=../../etc/passwd")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`invalid error page for status 404: "../../etc/passwd"`)))
+			})
+		})
+
+		context("when the error page in the web directory does not exist", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_ERROR_PAGES", "404=missing.html")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("error page for status 404 does not exist: htdocs/missing.html")))
+			})
+		})
+
+		context("when the error page in .httpd-errors does not exist", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_ERROR_PAGES", "503=.httpd-errors/missing.html")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("error page for status 503 does not exist: .httpd-errors/missing.html")))
+			})
+		})
+
+		context("when $BP_PHP_HTTPD_ENABLE_DEFAULT_ERROR_PAGES cannot be parsed into a bool", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_ENABLE_DEFAULT_ERROR_PAGES", "blah")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_ENABLE_DEFAULT_ERROR_PAGES")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse $BP_PHP_HTTPD_ENABLE_DEFAULT_ERROR_PAGES into boolean")))
+			})
+		})
+	})
+}
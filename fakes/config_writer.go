@@ -1,6 +1,10 @@
 package fakes
 
-import "sync"
+import (
+	"sync"
+
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+)
 
 type ConfigWriter struct {
 	WriteCall struct {
@@ -11,14 +15,14 @@ type ConfigWriter struct {
 			WorkingDir string
 		}
 		Returns struct {
-			String string
-			Error  error
+			Metadata phphttpd.Metadata
+			Error    error
 		}
-		Stub func(string, string) (string, error)
+		Stub func(string, string) (phphttpd.Metadata, error)
 	}
 }
 
-func (f *ConfigWriter) Write(param1 string, param2 string) (string, error) {
+func (f *ConfigWriter) Write(param1 string, param2 string) (phphttpd.Metadata, error) {
 	f.WriteCall.mutex.Lock()
 	defer f.WriteCall.mutex.Unlock()
 	f.WriteCall.CallCount++
@@ -27,5 +31,5 @@ func (f *ConfigWriter) Write(param1 string, param2 string) (string, error) {
 	if f.WriteCall.Stub != nil {
 		return f.WriteCall.Stub(param1, param2)
 	}
-	return f.WriteCall.Returns.String, f.WriteCall.Returns.Error
+	return f.WriteCall.Returns.Metadata, f.WriteCall.Returns.Error
 }
@@ -0,0 +1,197 @@
+package phphttpd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultFpmSocket = "127.0.0.1:9000"
+
+// fcgiURL returns the mod_proxy_fcgi URL for a php-fpm listen address, which
+// is either host:port or the path of a unix socket.
+func fcgiURL(socket string) string {
+	if strings.HasPrefix(socket, "/") {
+		return "unix:" + socket + "|fcgi://localhost"
+	}
+	return "fcgi://" + socket
+}
+
+// loadFpmSocket returns the address HTTPD proxies PHP requests to. When
+// $PHP_FPM_PATH points to the php-fpm configuration, the address its first
+// pool listens on is used, so that the two always agree. It also reports
+// whether the address was read from that configuration.
+func (c Config) loadFpmSocket(s settings) (string, bool, error) {
+	configured, ok := s.lookup("BP_PHP_HTTPD_FPM_SOCKET")
+	if ok {
+		if !isFpmSocket(configured) {
+			return "", false, fmt.Errorf("%s must be host:port or the absolute path of a unix socket: %q", s.describe("BP_PHP_HTTPD_FPM_SOCKET"), configured)
+		}
+	}
+
+	fpmConfig := os.Getenv("PHP_FPM_PATH")
+	if fpmConfig == "" {
+		if ok {
+			return configured, false, nil
+		}
+		return defaultFpmSocket, false, nil
+	}
+
+	listen, err := readFpmListen(fpmConfig, os.Getenv("PHP_HOME"), map[string]bool{})
+	if err != nil {
+		return "", false, err
+	}
+
+	if listen == "" {
+		c.logger.Subprocess("Warning: no listen directive found in $PHP_FPM_PATH (%s)", fpmConfig)
+		if ok {
+			return configured, false, nil
+		}
+		return defaultFpmSocket, false, nil
+	}
+
+	socket := normalizeFpmListen(listen)
+	if !isFpmSocket(socket) {
+		return "", false, fmt.Errorf("php-fpm listen address in $PHP_FPM_PATH (%s) must be host:port or the absolute path of a unix socket: %q", fpmConfig, listen)
+	}
+	if ok && configured != socket {
+		c.logger.Subprocess("Warning: %s (%s) does not match the address php-fpm listens on (%s), using %s", s.describe("BP_PHP_HTTPD_FPM_SOCKET"), configured, listen, socket)
+	}
+	c.logger.Debug.Subprocess(fmt.Sprintf("php-fpm listen address from %s: %s", fpmConfig, listen))
+
+	return socket, true, nil
+}
+
+// readFpmListen returns the listen directive of the first pool declared in
+// the php-fpm configuration at path, following include directives. Like
+// php-fpm, it resolves relative includes against the prefix, which is where
+// PHP is installed. Visited records the files read so far, so that an
+// include loop fails instead of recursing forever.
+func readFpmListen(path, prefix string, visited map[string]bool) (string, error) {
+	path = filepath.Clean(path)
+	if visited[path] {
+		return "", fmt.Errorf("php-fpm configuration %s is included more than once", path)
+	}
+	visited[path] = true
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("$PHP_FPM_PATH does not exist: %s", path)
+		}
+		// untested
+		return "", fmt.Errorf("failed to read $PHP_FPM_PATH: %w", err)
+	}
+	defer file.Close()
+
+	var pool string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			pool = strings.Trim(line, "[]")
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+		switch key {
+		case "listen":
+			if pool != "" && pool != "global" {
+				value = strings.ReplaceAll(value, "$pool", pool)
+				return os.Expand(value, expandFpmVariable), nil
+			}
+		case "include":
+			pattern := os.Expand(value, expandFpmVariable)
+			if !filepath.IsAbs(pattern) {
+				if prefix == "" {
+					return "", fmt.Errorf("relative include in %s cannot be resolved without $PHP_HOME, the php-fpm prefix: %q", path, value)
+				}
+				pattern = filepath.Join(prefix, pattern)
+			}
+
+			includes, err := filepath.Glob(pattern)
+			if err != nil {
+				return "", fmt.Errorf("invalid include in %s: %q", path, value)
+			}
+
+			for _, include := range includes {
+				listen, err := readFpmListen(include, prefix, visited)
+				if err != nil {
+					return "", err
+				}
+				if listen != "" {
+					return listen, nil
+				}
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		// untested
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return "", nil
+}
+
+// expandFpmVariable resolves the ${VAR} references php-fpm supports in its
+// configuration, leaving unknown ones untouched.
+func expandFpmVariable(name string) string {
+	if value, ok := os.LookupEnv(name); ok {
+		return value
+	}
+	return "${" + name + "}"
+}
+
+// normalizeFpmListen turns a php-fpm listen directive into an address HTTPD
+// can connect to. A bare port or a wildcard address listens on all
+// interfaces, which includes the loopback one.
+func normalizeFpmListen(listen string) string {
+	if strings.HasPrefix(listen, "/") {
+		return listen
+	}
+
+	host, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		return "127.0.0.1:" + listen
+	}
+
+	switch host {
+	case "", "0.0.0.0", "*":
+		return "127.0.0.1:" + port
+	case "::":
+		return "[::1]:" + port
+	}
+
+	return net.JoinHostPort(host, port)
+}
+
+// isFpmSocket reports whether the address is host:port or the absolute path
+// of a unix socket that can be used in a proxy URL as is. A ${VAR} reference
+// that could not be resolved is rejected.
+func isFpmSocket(socket string) bool {
+	if strings.ContainsAny(socket, " \"\\$") || strings.IndexFunc(socket, isControl) >= 0 {
+		return false
+	}
+
+	if strings.HasPrefix(socket, "/") {
+		return !strings.Contains(socket, "|")
+	}
+
+	host, port, err := net.SplitHostPort(socket)
+	return err == nil && host != "" && port != "" && !strings.Contains(socket, "/")
+}
@@ -0,0 +1,275 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testFpmSocket(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		fpmDir     string
+		buffer     *bytes.Buffer
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+
+		fpmDir, err = os.MkdirTemp("", "php-fpm")
+		Expect(err).NotTo(HaveOccurred())
+
+		buffer = bytes.NewBuffer(nil)
+		config = phphttpd.NewConfig(scribe.NewEmitter(buffer))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+		Expect(os.RemoveAll(fpmDir)).To(Succeed())
+	})
+
+	it("proxies to 127.0.0.1:9000 by default", func() {
+		metadata, err := config.Write(layerDir, workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(metadata.ConfigPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(ContainSubstring("SetHandler proxy:fcgi://127.0.0.1:9000"))
+	})
+
+	context("when $PHP_FPM_PATH is set", func() {
+		var fpmConfig string
+
+		it.Before(func() {
+			fpmConfig = filepath.Join(fpmDir, "php-fpm.conf")
+			Expect(os.Setenv("PHP_FPM_PATH", fpmConfig)).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("PHP_FPM_PATH")).To(Succeed())
+		})
+
+		it("uses the address the first pool listens on", func() {
+			Expect(os.WriteFile(fpmConfig, []byte(`[global]
+pid = /tmp/php-fpm.pid
+
+[www]
+; listen = 127.0.0.1:9000
+listen = 127.0.0.1:9001
+`), 0600)).To(Succeed())
+
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("SetHandler proxy:fcgi://127.0.0.1:9001"))
+			Expect(string(contents)).NotTo(ContainSubstring("127.0.0.1:9000"))
+		})
+
+		it("connects to a bare port on the loopback interface", func() {
+			Expect(os.WriteFile(fpmConfig, []byte("[www]\nlisten = 9002\n"), 0600)).To(Succeed())
+
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("SetHandler proxy:fcgi://127.0.0.1:9002"))
+		})
+
+		it("proxies to a unix socket", func() {
+			Expect(os.WriteFile(fpmConfig, []byte("[app]\nlisten = /tmp/php-fpm-$pool.sock\n"), 0600)).To(Succeed())
+
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("SetHandler proxy:unix:/tmp/php-fpm-app.sock|fcgi://localhost"))
+		})
+
+		it("follows include directives", func() {
+			Expect(os.MkdirAll(filepath.Join(fpmDir, "pool.d"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(fpmDir, "pool.d", "www.conf"), []byte("[www]\nlisten = 127.0.0.1:9003\n"), 0600)).To(Succeed())
+			Expect(os.WriteFile(fpmConfig, []byte("[global]\ninclude = "+filepath.Join(fpmDir, "pool.d", "*.conf")+"\n"), 0600)).To(Succeed())
+
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("SetHandler proxy:fcgi://127.0.0.1:9003"))
+		})
+
+		context("when an include is relative", func() {
+			it.Before(func() {
+				Expect(os.Setenv("PHP_HOME", filepath.Join(fpmDir, "php"))).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("PHP_HOME")).To(Succeed())
+			})
+
+			it("resolves it against the php-fpm prefix", func() {
+				Expect(os.MkdirAll(filepath.Join(fpmDir, "php", "etc", "php-fpm.d"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(fpmDir, "php", "etc", "php-fpm.d", "www.conf"), []byte("[www]\nlisten = 127.0.0.1:9004\n"), 0600)).To(Succeed())
+				Expect(os.WriteFile(fpmConfig, []byte("[global]\ninclude = etc/php-fpm.d/*.conf\n"), 0600)).To(Succeed())
+
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring("SetHandler proxy:fcgi://127.0.0.1:9004"))
+			})
+		})
+
+		context("when $BP_PHP_HTTPD_FPM_SOCKET disagrees with php-fpm", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_FPM_SOCKET", "127.0.0.1:9000")).To(Succeed())
+				Expect(os.WriteFile(fpmConfig, []byte("[www]\nlisten = 0.0.0.0:9004\n"), 0600)).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_FPM_SOCKET")).To(Succeed())
+			})
+
+			it("warns and uses the php-fpm address", func() {
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring("SetHandler proxy:fcgi://127.0.0.1:9004"))
+				Expect(buffer.String()).To(ContainSubstring("Warning: $BP_PHP_HTTPD_FPM_SOCKET (127.0.0.1:9000) does not match the address php-fpm listens on (0.0.0.0:9004), using 127.0.0.1:9004"))
+			})
+		})
+	})
+
+	context("when $BP_PHP_HTTPD_FPM_SOCKET is set", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_FPM_SOCKET", "/tmp/php-fpm.sock")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_FPM_SOCKET")).To(Succeed())
+		})
+
+		it("proxies to the configured address", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`Define fcgi-listener "unix:/tmp/php-fpm.sock|fcgi://localhost`))
+		})
+	})
+
+	context("failure cases", func() {
+		context("when $BP_PHP_HTTPD_FPM_SOCKET is invalid", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_FPM_SOCKET", "localhost")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_FPM_SOCKET")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(`$BP_PHP_HTTPD_FPM_SOCKET must be host:port or the absolute path of a unix socket: "localhost"`))
+			})
+		})
+
+		context("when $PHP_FPM_PATH does not exist", func() {
+			it.Before(func() {
+				Expect(os.Setenv("PHP_FPM_PATH", filepath.Join(fpmDir, "missing.conf"))).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("PHP_FPM_PATH")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("$PHP_FPM_PATH does not exist")))
+			})
+		})
+
+		context("when the php-fpm configuration includes itself", func() {
+			var fpmConfig string
+
+			it.Before(func() {
+				fpmConfig = filepath.Join(fpmDir, "php-fpm.conf")
+				Expect(os.WriteFile(fpmConfig, []byte("[global]\ninclude = "+filepath.Join(fpmDir, "*.conf")+"\n"), 0600)).To(Succeed())
+				Expect(os.Setenv("PHP_FPM_PATH", fpmConfig)).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("PHP_FPM_PATH")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError("php-fpm configuration " + fpmConfig + " is included more than once"))
+			})
+		})
+
+		context("when a relative include cannot be resolved", func() {
+			var fpmConfig string
+
+			it.Before(func() {
+				fpmConfig = filepath.Join(fpmDir, "php-fpm.conf")
+				Expect(os.WriteFile(fpmConfig, []byte("[global]\ninclude = etc/php-fpm.d/*.conf\n"), 0600)).To(Succeed())
+				Expect(os.Setenv("PHP_FPM_PATH", fpmConfig)).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("PHP_FPM_PATH")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(`relative include in ` + fpmConfig + ` cannot be resolved without $PHP_HOME, the php-fpm prefix: "etc/php-fpm.d/*.conf"`))
+			})
+		})
+
+		context("when php-fpm listens on an address with an unresolved variable", func() {
+			var fpmConfig string
+
+			it.Before(func() {
+				fpmConfig = filepath.Join(fpmDir, "php-fpm.conf")
+				Expect(os.WriteFile(fpmConfig, []byte("[www]\nlisten = ${PHP_HTTPD_TEST_UNSET}/php-fpm.sock\n"), 0600)).To(Succeed())
+				Expect(os.Setenv("PHP_FPM_PATH", fpmConfig)).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("PHP_FPM_PATH")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(`php-fpm listen address in $PHP_FPM_PATH (` + fpmConfig + `) must be host:port or the absolute path of a unix socket: "${PHP_HTTPD_TEST_UNSET}/php-fpm.sock"`))
+			})
+		})
+	})
+}
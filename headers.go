@@ -0,0 +1,43 @@
+package phphttpd
+
+import (
+	"fmt"
+	"net/textproto"
+	"strings"
+)
+
+// ResponseHeader is a header HTTPD sets on every response, including error
+// responses.
+type ResponseHeader struct {
+	Name  string
+	Value string
+}
+
+func loadResponseHeaders(s settings) ([]ResponseHeader, error) {
+	entries, err := s.lookupKeyValues("BP_PHP_HTTPD_RESPONSE_HEADERS")
+	if err != nil {
+		return nil, err
+	}
+
+	var headers []ResponseHeader
+	for _, entry := range entries {
+		if !isHeaderName(entry.Key) {
+			return nil, fmt.Errorf("failed to parse %s: invalid header name: %q", s.describe("BP_PHP_HTTPD_RESPONSE_HEADERS"), entry.Key)
+		}
+
+		if strings.ContainsAny(entry.Value, "\"\\") || strings.ContainsFunc(entry.Value, isControl) {
+			return nil, fmt.Errorf("failed to parse %s: value for %s must not contain quotes, backslashes or control characters: %q", s.describe("BP_PHP_HTTPD_RESPONSE_HEADERS"), entry.Key, entry.Value)
+		}
+
+		headers = append(headers, ResponseHeader{
+			Name:  textproto.CanonicalMIMEHeaderKey(entry.Key),
+			Value: entry.Value,
+		})
+	}
+
+	return headers, nil
+}
+
+func isControl(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
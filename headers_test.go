@@ -0,0 +1,121 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testResponseHeaders(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+
+		config = phphttpd.NewConfig(scribe.NewEmitter(bytes.NewBuffer(nil)))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("does not set any response headers by default", func() {
+		metadata, err := config.Write(layerDir, workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(metadata.ConfigPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).NotTo(ContainSubstring("Response headers"))
+	})
+
+	context("when $BP_PHP_HTTPD_RESPONSE_HEADERS is set", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_RESPONSE_HEADERS", "x-frame-options=DENY,X-Content-Type-Options=nosniff")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_RESPONSE_HEADERS")).To(Succeed())
+		})
+
+		it("sets the headers on every response", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`Header always set X-Frame-Options "DENY"
+Header always set X-Content-Type-Options "nosniff"`))
+		})
+	})
+
+	context("when .httpd.toml declares headers", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.toml"), []byte(`
+[headers]
+Content-Security-Policy = "default-src 'self'; img-src 'self' data:, https:"
+Referrer-Policy = "same-origin"
+`), 0644)).To(Succeed())
+		})
+
+		it("sets the headers in the declared order", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`Header always set Content-Security-Policy "default-src 'self'; img-src 'self' data:, https:"
+Header always set Referrer-Policy "same-origin"`))
+		})
+	})
+
+	context("failure cases", func() {
+		context("when a header name is invalid", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_RESPONSE_HEADERS", "X Frame=DENY")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_RESPONSE_HEADERS")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`failed to parse $BP_PHP_HTTPD_RESPONSE_HEADERS: invalid header name: "X Frame"`)))
+			})
+		})
+
+		context("when a header value contains a quote", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.toml"), []byte(`
+[headers]
+X-Test = "a\" b"
+`), 0644)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse headers (.httpd.toml, line 2): value for X-Test must not contain quotes")))
+			})
+		})
+	})
+}
@@ -0,0 +1,59 @@
+package phphttpd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// HealthCheckResponseFile is the name of the file, written into the config
+// layer, that HTTPD serves for the health check endpoint.
+const HealthCheckResponseFile = "healthcheck"
+
+// HealthCheck describes an optional endpoint intended for liveness and
+// readiness probes. When FpmPingPath is empty the endpoint is served by HTTPD
+// itself, otherwise it is proxied to the php-fpm ping path.
+type HealthCheck struct {
+	Path         string
+	FpmPingPath  string
+	AccessLog    bool
+	ResponseFile string
+}
+
+func loadHealthCheck(s settings, layerPath string) (HealthCheck, error) {
+	healthCheck := HealthCheck{
+		Path:        s.get("BP_PHP_HTTPD_HEALTHCHECK_PATH"),
+		FpmPingPath: s.get("BP_PHP_HTTPD_HEALTHCHECK_FPM_PING_PATH"),
+	}
+
+	if healthCheck.Path == "" {
+		return HealthCheck{}, nil
+	}
+
+	err := validateURLPath(s, "BP_PHP_HTTPD_HEALTHCHECK_PATH", healthCheck.Path)
+	if err != nil {
+		return HealthCheck{}, err
+	}
+
+	if healthCheck.FpmPingPath != "" {
+		err = validateURLPath(s, "BP_PHP_HTTPD_HEALTHCHECK_FPM_PING_PATH", healthCheck.FpmPingPath)
+		if err != nil {
+			return HealthCheck{}, err
+		}
+	}
+
+	healthCheck.AccessLog, err = s.lookupBool("BP_PHP_HTTPD_HEALTHCHECK_ACCESS_LOG", true)
+	if err != nil {
+		return HealthCheck{}, err
+	}
+
+	if healthCheck.FpmPingPath == "" {
+		healthCheck.ResponseFile = filepath.Join(layerPath, HealthCheckResponseFile)
+		err = os.WriteFile(healthCheck.ResponseFile, []byte("OK\n"), 0644)
+		if err != nil {
+			return HealthCheck{}, fmt.Errorf("failed to write health check response: %w", err)
+		}
+	}
+
+	return healthCheck, nil
+}
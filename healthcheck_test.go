@@ -0,0 +1,194 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testHealthCheck(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+
+		config = phphttpd.NewConfig(scribe.NewEmitter(bytes.NewBuffer(nil)))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("does not render a health check endpoint by default", func() {
+		metadata, err := config.Write(layerDir, workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(metadata.ConfigPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).NotTo(ContainSubstring("alias_module"))
+		Expect(string(contents)).NotTo(ContainSubstring("Health check endpoint"))
+		Expect(filepath.Join(layerDir, phphttpd.HealthCheckResponseFile)).NotTo(BeAnExistingFile())
+	})
+
+	context("when $BP_PHP_HTTPD_HEALTHCHECK_PATH is set", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_HEALTHCHECK_PATH", "/healthz")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_HEALTHCHECK_PATH")).To(Succeed())
+		})
+
+		it("serves the endpoint from HTTPD and exempts it from the HTTPS redirect", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			responseFile := filepath.Join(layerDir, phphttpd.HealthCheckResponseFile)
+			Expect(os.ReadFile(responseFile)).To(Equal([]byte("OK\n")))
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("LoadModule alias_module modules/mod_alias.so"))
+			Expect(string(contents)).To(ContainSubstring("RewriteCond %{REQUEST_URI} !=/healthz"))
+			Expect(string(contents)).To(ContainSubstring(`Alias "/healthz" "` + responseFile + `"`))
+			Expect(string(contents)).To(ContainSubstring(`<Location "/healthz">
+    ForceType text/plain
+    Require all granted
+</Location>`))
+			Expect(string(contents)).To(ContainSubstring(`CustomLog "/proc/self/fd/1" extended` + "\n"))
+			Expect(string(contents)).NotTo(ContainSubstring("dontlog"))
+		})
+
+		context("when $BP_PHP_HTTPD_HEALTHCHECK_FPM_PING_PATH is set", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_HEALTHCHECK_FPM_PING_PATH", "/ping")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_HEALTHCHECK_FPM_PING_PATH")).To(Succeed())
+			})
+
+			it("proxies the endpoint to the FPM ping path", func() {
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(filepath.Join(layerDir, phphttpd.HealthCheckResponseFile)).NotTo(BeAnExistingFile())
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).NotTo(ContainSubstring("alias_module"))
+				Expect(string(contents)).To(ContainSubstring(`<Location "/healthz">
+    ProxyFCGISetEnvIf "true" SCRIPT_NAME "/ping"
+    SetHandler proxy:fcgi://127.0.0.1:9000
+    Require all granted
+</Location>`))
+			})
+		})
+
+		context("when $BP_PHP_HTTPD_HEALTHCHECK_ACCESS_LOG is false", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_HEALTHCHECK_ACCESS_LOG", "false")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_HEALTHCHECK_ACCESS_LOG")).To(Succeed())
+			})
+
+			it("excludes the endpoint from the access log", func() {
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring(`SetEnvIf Request_URI "^/healthz$" dontlog`))
+				Expect(string(contents)).To(ContainSubstring(`CustomLog "/proc/self/fd/1" extended env=!dontlog`))
+			})
+		})
+	})
+
+	context("failure cases", func() {
+		context("when the health check path is not absolute", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_HEALTHCHECK_PATH", "healthz")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_HEALTHCHECK_PATH")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("$BP_PHP_HTTPD_HEALTHCHECK_PATH must start with '/'")))
+			})
+		})
+
+		context("when the health check path contains a quote", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_HEALTHCHECK_PATH", `/health" x`)).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_HEALTHCHECK_PATH")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(`$BP_PHP_HTTPD_HEALTHCHECK_PATH must start with '/' and must not contain quotes or whitespace: "/health\" x"`))
+			})
+		})
+
+		context("when the FPM ping path is not absolute", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_HEALTHCHECK_PATH", "/healthz")).To(Succeed())
+				Expect(os.Setenv("BP_PHP_HTTPD_HEALTHCHECK_FPM_PING_PATH", "ping")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_HEALTHCHECK_PATH")).To(Succeed())
+				Expect(os.Unsetenv("BP_PHP_HTTPD_HEALTHCHECK_FPM_PING_PATH")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("$BP_PHP_HTTPD_HEALTHCHECK_FPM_PING_PATH must start with '/'")))
+			})
+		})
+
+		context("when the access log setting cannot be parsed into a bool", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_HEALTHCHECK_PATH", "/healthz")).To(Succeed())
+				Expect(os.Setenv("BP_PHP_HTTPD_HEALTHCHECK_ACCESS_LOG", "blah")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_HEALTHCHECK_PATH")).To(Succeed())
+				Expect(os.Unsetenv("BP_PHP_HTTPD_HEALTHCHECK_ACCESS_LOG")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse $BP_PHP_HTTPD_HEALTHCHECK_ACCESS_LOG into boolean")))
+			})
+		})
+	})
+}
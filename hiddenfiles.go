@@ -0,0 +1,87 @@
+package phphttpd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// HiddenFiles are the paths starting with a dot that are served despite the
+// deny-by-default policy for hidden files, relative to the web directory.
+type HiddenFiles struct {
+	Directories []string
+	Files       []string
+
+	// WellKnownFrontController is the script, relative to the web directory,
+	// that handles requests under /.well-known/ for files that do not exist.
+	WellKnownFrontController string
+}
+
+func loadHiddenFiles(s settings, workingDir, webDir string) (HiddenFiles, error) {
+	root := filepath.Join(workingDir, webDir)
+	hidden := HiddenFiles{Directories: []string{".well-known"}}
+
+	for _, entry := range s.lookupList("BP_PHP_HTTPD_ALLOW_HIDDEN_PATHS") {
+		trimmed := strings.Trim(entry, "/")
+		clean := path.Clean(trimmed)
+		if !isAppPath(trimmed) || !isHiddenPath(clean) {
+			return HiddenFiles{}, fmt.Errorf("%s entries must be hidden paths inside the web directory, such as .identity: %q", s.describe("BP_PHP_HTTPD_ALLOW_HIDDEN_PATHS"), entry)
+		}
+
+		directory := strings.HasSuffix(entry, "/")
+		if !directory {
+			var err error
+			directory, err = isDirectory(filepath.Join(root, clean))
+			if err != nil {
+				return HiddenFiles{}, err
+			}
+		}
+
+		if directory {
+			hidden.Directories = appendUnique(hidden.Directories, clean)
+		} else {
+			hidden.Files = appendUnique(hidden.Files, clean)
+		}
+	}
+
+	controller, ok := s.lookup("BP_PHP_HTTPD_WELL_KNOWN_FRONT_CONTROLLER")
+	if ok && controller != "" {
+		clean, valid := cleanPagePath(controller)
+		if !valid || clean == "." || isHiddenPath(clean) || strings.ContainsAny(clean, "\\") || strings.IndexFunc(clean, isControl) >= 0 {
+			return HiddenFiles{}, fmt.Errorf("%s must be a script inside the web directory: %q", s.describe("BP_PHP_HTTPD_WELL_KNOWN_FRONT_CONTROLLER"), controller)
+		}
+
+		exists, err := isDirectory(root)
+		if err != nil {
+			return HiddenFiles{}, err
+		}
+		if exists {
+			_, err = os.Stat(filepath.Join(root, clean))
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					return HiddenFiles{}, fmt.Errorf("%s does not exist in the web directory: %s", s.describe("BP_PHP_HTTPD_WELL_KNOWN_FRONT_CONTROLLER"), clean)
+				}
+				// untested
+				return HiddenFiles{}, err
+			}
+		}
+
+		hidden.WellKnownFrontController = clean
+	}
+
+	return hidden, nil
+}
+
+// isHiddenPath reports whether any element of the slash-separated path starts
+// with a dot.
+func isHiddenPath(p string) bool {
+	for _, element := range strings.Split(p, "/") {
+		if strings.HasPrefix(element, ".") && element != "." && element != ".." {
+			return true
+		}
+	}
+	return false
+}
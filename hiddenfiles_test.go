@@ -0,0 +1,151 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testHiddenFiles(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+
+		config = phphttpd.NewConfig(scribe.NewEmitter(bytes.NewBuffer(nil)))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("denies hidden paths except for .well-known", func() {
+		metadata, err := config.Write(layerDir, workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(metadata.ConfigPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(ContainSubstring(`<DirectoryMatch "^\.|\/\.">
+    Require all denied
+</DirectoryMatch>
+
+<DirectoryMatch "/\.well-known(/|$)">
+    Require all granted
+</DirectoryMatch>`))
+		Expect(string(contents)).NotTo(ContainSubstring(`RewriteRule "^/\.well-known/"`))
+	})
+
+	context("when $BP_PHP_HTTPD_ALLOW_HIDDEN_PATHS is set", func() {
+		it.Before(func() {
+			Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs", "app", ".config"), os.ModePerm)).To(Succeed())
+			Expect(os.Setenv("BP_PHP_HTTPD_ALLOW_HIDDEN_PATHS", ".identity, app/.config, .assets/")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_ALLOW_HIDDEN_PATHS")).To(Succeed())
+		})
+
+		it("allows the listed files and directories", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`<DirectoryMatch "/app/\.config(/|$)">
+    Require all granted
+</DirectoryMatch>`))
+			Expect(string(contents)).To(ContainSubstring(`<DirectoryMatch "/\.assets(/|$)">`))
+			Expect(string(contents)).To(ContainSubstring(`<Location "/.identity">
+    Require all granted
+</Location>`))
+		})
+	})
+
+	context("when $BP_PHP_HTTPD_WELL_KNOWN_FRONT_CONTROLLER is set", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "htdocs", "index.php"), nil, 0600)).To(Succeed())
+			Expect(os.Setenv("BP_PHP_HTTPD_WELL_KNOWN_FRONT_CONTROLLER", "/index.php")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_WELL_KNOWN_FRONT_CONTROLLER")).To(Succeed())
+		})
+
+		it("routes missing .well-known files to the front controller", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`RewriteCond "%{DOCUMENT_ROOT}%{REQUEST_URI}" !-f
+RewriteRule "^/\.well-known/" "/index.php" [PT,L]`))
+		})
+	})
+
+	context("failure cases", func() {
+		context("when an allowed path is not hidden", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_ALLOW_HIDDEN_PATHS", "admin")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_ALLOW_HIDDEN_PATHS")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(`$BP_PHP_HTTPD_ALLOW_HIDDEN_PATHS entries must be hidden paths inside the web directory, such as .identity: "admin"`))
+			})
+		})
+
+		context("when an allowed path leaves the web directory", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_ALLOW_HIDDEN_PATHS", "../.env")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_ALLOW_HIDDEN_PATHS")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`must be hidden paths inside the web directory, such as .identity: "../.env"`)))
+			})
+		})
+
+		context("when the front controller does not exist", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_WELL_KNOWN_FRONT_CONTROLLER", "index.php")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_WELL_KNOWN_FRONT_CONTROLLER")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError("$BP_PHP_HTTPD_WELL_KNOWN_FRONT_CONTROLLER does not exist in the web directory: index.php"))
+			})
+		})
+	})
+}
@@ -0,0 +1,206 @@
+package phphttpd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// overrideClasses are the directive groups AllowOverride accepts.
+var overrideClasses = []string{"AuthConfig", "FileInfo", "Indexes", "Limit", "Options"}
+
+// HtaccessFile is a .htaccess file whose directives are rendered into a
+// <Directory> section of the main configuration.
+type HtaccessFile struct {
+	// Path is relative to the web directory, or to the application for the
+	// web directories of virtual hosts and mounts.
+	Path      string
+	Directory string
+	Lines     []string
+}
+
+var directorySection = regexp.MustCompile(`(?i)^\s*</?Directory`)
+
+// loadAllowOverride returns the value of the web directory's AllowOverride
+// directive. It defaults to All, or None when the .htaccess files are
+// compiled into the configuration.
+func loadAllowOverride(s settings, compile bool) (string, error) {
+	value, ok := s.lookup("BP_PHP_HTTPD_ALLOW_OVERRIDE")
+	if !ok || value == "" {
+		if compile {
+			return "None", nil
+		}
+		return "All", nil
+	}
+
+	classes := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' })
+	if len(classes) == 1 && (strings.EqualFold(classes[0], "All") || strings.EqualFold(classes[0], "None")) {
+		return strings.ToUpper(classes[0][:1]) + strings.ToLower(classes[0][1:]), nil
+	}
+
+	for i, class := range classes {
+		valid := false
+		for _, known := range overrideClasses {
+			if strings.EqualFold(class, known) {
+				classes[i] = known
+				valid = true
+			}
+		}
+
+		if !valid {
+			return "", fmt.Errorf("%s must be All, None or a list of %s: %q", s.describe("BP_PHP_HTTPD_ALLOW_OVERRIDE"), strings.Join(overrideClasses, ", "), value)
+		}
+	}
+
+	return strings.Join(classes, " "), nil
+}
+
+// htaccessFile is the location of a .htaccess file. Name is the path shown
+// in the build log and in the configuration.
+type htaccessFile struct {
+	Name string
+	Path string
+}
+
+// htaccessRoots returns the main web directory followed by the web
+// directories of the virtual hosts and mounts, since they all share the
+// same AllowOverride setting.
+func htaccessRoots(workingDir, webDir string, hosts []VirtualHost, mounts []Mount) []string {
+	roots := []string{filepath.Join(workingDir, webDir)}
+	for _, host := range hosts {
+		roots = appendUnique(roots, host.Root)
+	}
+	for _, mount := range mounts {
+		roots = appendUnique(roots, mount.Root)
+	}
+	return roots
+}
+
+// findHtaccessFiles returns the .htaccess files in the given web directories,
+// outside of hidden directories and in lexical order for each directory. The
+// files of the first directory are named relative to it, the others relative
+// to the application. A file below more than one of the directories is only
+// returned once.
+func findHtaccessFiles(workingDir string, roots []string) ([]htaccessFile, error) {
+	var files []htaccessFile
+	seen := map[string]bool{}
+	for i, root := range roots {
+		paths, err := findHtaccessPaths(root)
+		if err != nil {
+			return nil, err
+		}
+
+		base := root
+		if i > 0 {
+			base = workingDir
+		}
+
+		for _, path := range paths {
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+
+			name, err := filepath.Rel(base, path)
+			if err != nil {
+				// untested
+				return nil, err
+			}
+			files = append(files, htaccessFile{Name: filepath.ToSlash(name), Path: path})
+		}
+	}
+
+	return files, nil
+}
+
+// findHtaccessPaths returns the absolute paths of the .htaccess files in a
+// web directory, outside of hidden directories and in lexical order.
+func findHtaccessPaths(root string) ([]string, error) {
+	exists, err := isDirectory(root)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	var paths []string
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if path != root && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if entry.Name() != ".htaccess" || !entry.Type().IsRegular() {
+			return nil
+		}
+
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find .htaccess files: %w", err)
+	}
+
+	return paths, nil
+}
+
+// compileHtaccessFiles reads the .htaccess files of the web directories so
+// that they can be rendered into the configuration. Directives of mod_php,
+// which is never loaded, are commented out.
+func compileHtaccessFiles(workingDir string, roots []string) ([]HtaccessFile, error) {
+	found, err := findHtaccessFiles(workingDir, roots)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []HtaccessFile
+	for _, file := range found {
+		rel := file.Name
+		directory := filepath.Dir(file.Path)
+		if strings.ContainsAny(directory, "\"\\") || strings.IndexFunc(directory, isControl) >= 0 {
+			return nil, fmt.Errorf("cannot compile %s: its directory name contains quotes or control characters", rel)
+		}
+
+		content, err := os.ReadFile(file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+
+		var lines []string
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		for scanner.Scan() {
+			line := strings.TrimRight(scanner.Text(), " \t\r")
+			if directorySection.MatchString(line) {
+				return nil, fmt.Errorf("cannot compile %s: <Directory> sections are not allowed in .htaccess files", rel)
+			}
+			if strings.IndexFunc(line, func(r rune) bool { return r != '\t' && isControl(r) }) >= 0 {
+				return nil, fmt.Errorf("cannot compile %s: it contains control characters", rel)
+			}
+
+			switch {
+			case line == "":
+				lines = append(lines, "")
+			case isPHPDirective(directiveName(line)):
+				lines = append(lines, "    # "+strings.TrimSpace(line)+" (requires mod_php)")
+			default:
+				lines = append(lines, "    "+line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+
+		files = append(files, HtaccessFile{Path: rel, Directory: directory, Lines: lines})
+	}
+
+	return files, nil
+}
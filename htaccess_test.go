@@ -0,0 +1,220 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testHtaccess(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		webDir     string
+		buffer     *bytes.Buffer
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		webDir = filepath.Join(workingDir, "htdocs")
+		Expect(os.MkdirAll(webDir, os.ModePerm)).To(Succeed())
+
+		buffer = bytes.NewBuffer(nil)
+		config = phphttpd.NewConfig(scribe.NewEmitter(buffer))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("allows all overrides by default", func() {
+		metadata, err := config.Write(layerDir, workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(metadata.ConfigPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(ContainSubstring(`<Directory "` + webDir + `">
+    Options SymLinksIfOwnerMatch
+    AllowOverride All
+    Require all granted
+</Directory>`))
+	})
+
+	context("when $BP_PHP_HTTPD_ALLOW_OVERRIDE is set", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_ALLOW_OVERRIDE", "fileinfo,AuthConfig")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_ALLOW_OVERRIDE")).To(Succeed())
+		})
+
+		it("allows the listed overrides", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("AllowOverride FileInfo AuthConfig\n"))
+		})
+	})
+
+	context("when $BP_PHP_HTTPD_COMPILE_HTACCESS is true", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_COMPILE_HTACCESS", "true")).To(Succeed())
+
+			Expect(os.WriteFile(filepath.Join(webDir, ".htaccess"), []byte("RewriteEngine On\n\tRewriteRule ^ index.php [L]\n"), 0600)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(webDir, "admin"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(webDir, "admin", ".htaccess"), []byte("Require all denied\n"), 0600)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(webDir, ".git"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(webDir, ".git", ".htaccess"), []byte("Require all granted\n"), 0600)).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_COMPILE_HTACCESS")).To(Succeed())
+		})
+
+		it("inlines each .htaccess file and disables overrides", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("AllowOverride None\n    Require all granted"))
+			Expect(string(contents)).To(ContainSubstring(`# Compiled from .htaccess
+<Directory "` + webDir + `">
+    RewriteEngine On
+    	RewriteRule ^ index.php [L]
+</Directory>
+
+# Compiled from admin/.htaccess
+<Directory "` + filepath.Join(webDir, "admin") + `">
+    Require all denied
+</Directory>`))
+			Expect(string(contents)).NotTo(ContainSubstring(".git/.htaccess"))
+
+			Expect(buffer.String()).To(ContainSubstring("Compiling .htaccess files into the configuration:"))
+			Expect(buffer.String()).To(ContainSubstring("admin/.htaccess"))
+		})
+
+		context("when AllowOverride is set too", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_ALLOW_OVERRIDE", "All")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_ALLOW_OVERRIDE")).To(Succeed())
+			})
+
+			it("keeps the configured value", func() {
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring("AllowOverride All\n"))
+			})
+		})
+
+		context("when precompressed files are served too", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_SERVE_PRECOMPRESSED", "true")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_SERVE_PRECOMPRESSED")).To(Succeed())
+			})
+
+			it("renders the compiled rules after every other section for the web directory", func() {
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+
+				compiled := `# Compiled from .htaccess
+<Directory "` + webDir + `">
+    RewriteEngine On`
+				Expect(string(contents)).To(ContainSubstring(compiled))
+				Expect(strings.LastIndex(string(contents), `<Directory "`+webDir+`">`)).To(Equal(strings.Index(string(contents), compiled) + len("# Compiled from .htaccess\n")))
+			})
+		})
+
+		context("when .httpd.toml declares a mount", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(workingDir, "admin", "public"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "admin", "public", ".htaccess"), []byte("Header set X-Admin yes\n"), 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.toml"), []byte(`
+[[mounts]]
+path = "/admin/"
+web_directory = "admin/public"
+`), 0644)).To(Succeed())
+			})
+
+			it("compiles its .htaccess files and disables overrides for it too", func() {
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring(`# Compiled from admin/public/.htaccess
+<Directory "` + filepath.Join(workingDir, "admin", "public") + `">
+    Header set X-Admin yes
+</Directory>`))
+				Expect(string(contents)).NotTo(ContainSubstring("AllowOverride All"))
+
+				Expect(buffer.String()).To(ContainSubstring("admin/public/.htaccess"))
+			})
+		})
+	})
+
+	context("failure cases", func() {
+		context("when $BP_PHP_HTTPD_ALLOW_OVERRIDE is invalid", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_ALLOW_OVERRIDE", "FileInfo Everything")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_ALLOW_OVERRIDE")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(`$BP_PHP_HTTPD_ALLOW_OVERRIDE must be All, None or a list of AuthConfig, FileInfo, Indexes, Limit, Options: "FileInfo Everything"`))
+			})
+		})
+
+		context("when a .htaccess file contains a <Directory> section", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_COMPILE_HTACCESS", "true")).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(webDir, ".htaccess"), []byte("</Directory>\n<Directory />\n"), 0600)).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_COMPILE_HTACCESS")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("cannot compile .htaccess: <Directory> sections are not allowed in .htaccess files")))
+			})
+		})
+	})
+}
@@ -0,0 +1,143 @@
+package phphttpd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// directiveModules maps directives commonly found in .htaccess files to the
+// module that provides them, for the modules the configuration does not
+// always load.
+var directiveModules = map[string]string{
+	"expiresactive":            "expires",
+	"expiresbytype":            "expires",
+	"expiresdefault":           "expires",
+	"redirect":                 "alias",
+	"redirectmatch":            "alias",
+	"redirectpermanent":        "alias",
+	"redirecttemp":             "alias",
+	"order":                    "access_compat",
+	"allow":                    "access_compat",
+	"deny":                     "access_compat",
+	"authtype":                 "auth_basic",
+	"authbasicprovider":        "auth_basic",
+	"authuserfile":             "authn_file",
+	"authgroupfile":            "authz_groupfile",
+	"indexoptions":             "autoindex",
+	"indexignore":              "autoindex",
+	"addicon":                  "autoindex",
+	"headername":               "autoindex",
+	"readmename":               "autoindex",
+	"languagepriority":         "negotiation",
+	"forcelanguagepriority":    "negotiation",
+	"brotlicompressionquality": "brotli",
+	"substitute":               "substitute",
+	"xbithack":                 "include",
+}
+
+// reportHtaccessFiles lists the .htaccess files of the web directories and
+// warns about the directives in them that will not work with the loaded
+// modules. Directives inside <IfModule> sections are skipped, since HTTPD
+// ignores them when the module is missing.
+func (c Config) reportHtaccessFiles(workingDir string, roots []string, compiled bool, modules []string) error {
+	files, err := findHtaccessFiles(workingDir, roots)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	loaded := map[string]bool{}
+	for _, module := range modules {
+		loaded[module] = true
+	}
+
+	if compiled {
+		c.logger.Subprocess("Compiling .htaccess files into the configuration:")
+	} else {
+		c.logger.Subprocess("Found .htaccess files:")
+	}
+
+	for _, file := range files {
+		c.logger.Action("%s", file.Name)
+
+		content, err := os.ReadFile(file.Path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+
+		for _, issue := range lintHtaccess(content, loaded) {
+			c.logger.Detail("Warning: %s", issue)
+		}
+	}
+
+	return nil
+}
+
+func lintHtaccess(content []byte, loaded map[string]bool) []string {
+	var issues []string
+	depth := 0
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		name := strings.ToLower(directiveName(line))
+
+		switch {
+		case name == "<ifmodule":
+			depth++
+			continue
+		case name == "</ifmodule>" || name == "</ifmodule":
+			if depth > 0 {
+				depth--
+			}
+			continue
+		case depth > 0:
+			continue
+		}
+
+		if isPHPDirective(name) {
+			issues = append(issues, fmt.Sprintf("line %d: %s only works with mod_php, set it in a php-fpm pool configuration in .php.fpm.d instead: %s", number, directiveName(line), fpmEquivalent(line)))
+			continue
+		}
+
+		module, ok := directiveModules[name]
+		if ok && !loaded[module] {
+			issues = append(issues, fmt.Sprintf("line %d: %s requires mod_%s, which is not loaded; add %s to $BP_PHP_HTTPD_MODULES", number, directiveName(line), module, module))
+		}
+	}
+
+	return issues
+}
+
+// directiveName returns the first word of a configuration line, or the empty
+// string for blank lines and comments.
+func directiveName(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+		return ""
+	}
+	return fields[0]
+}
+
+func isPHPDirective(name string) bool {
+	switch strings.ToLower(name) {
+	case "php_value", "php_flag", "php_admin_value", "php_admin_flag":
+		return true
+	}
+	return false
+}
+
+// fpmEquivalent rewrites a mod_php directive, such as
+// "php_value upload_max_filesize 64M", into the php-fpm pool setting that has
+// the same effect, "php_value[upload_max_filesize] = 64M".
+func fpmEquivalent(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return strings.TrimSpace(line)
+	}
+	return fmt.Sprintf("%s[%s] = %s", strings.ToLower(fields[0]), fields[1], strings.Trim(strings.Join(fields[2:], " "), `"`))
+}
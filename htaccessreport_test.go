@@ -0,0 +1,111 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testHtaccessReport(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		webDir     string
+		buffer     *bytes.Buffer
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		webDir = filepath.Join(workingDir, "htdocs")
+		Expect(os.MkdirAll(filepath.Join(webDir, "blog"), os.ModePerm)).To(Succeed())
+
+		Expect(os.WriteFile(filepath.Join(webDir, ".htaccess"), []byte(`RewriteEngine On
+php_value upload_max_filesize 64M
+php_flag display_errors off
+ExpiresActive On
+<IfModule mod_expires.c>
+    ExpiresDefault "access plus 1 month"
+</IfModule>
+`), 0600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(webDir, "blog", ".htaccess"), []byte("Header set X-Blog 1\n"), 0600)).To(Succeed())
+
+		buffer = bytes.NewBuffer(nil)
+		config = phphttpd.NewConfig(scribe.NewEmitter(buffer))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("lists the .htaccess files and the directives that will not work", func() {
+		_, err := config.Write(layerDir, workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(buffer.String()).To(ContainSubstring(`    Found .htaccess files:
+      .htaccess
+        Warning: line 2: php_value only works with mod_php, set it in a php-fpm pool configuration in .php.fpm.d instead: php_value[upload_max_filesize] = 64M
+        Warning: line 3: php_flag only works with mod_php, set it in a php-fpm pool configuration in .php.fpm.d instead: php_flag[display_errors] = off
+        Warning: line 4: ExpiresActive requires mod_expires, which is not loaded; add expires to $BP_PHP_HTTPD_MODULES
+      blog/.htaccess
+`))
+		Expect(buffer.String()).NotTo(ContainSubstring("line 6"))
+	})
+
+	context("when the module is loaded", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_MODULES", "expires")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_MODULES")).To(Succeed())
+		})
+
+		it("does not warn about its directives", func() {
+			_, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).NotTo(ContainSubstring("ExpiresActive requires"))
+		})
+	})
+
+	context("when the .htaccess files are compiled", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_COMPILE_HTACCESS", "true")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_COMPILE_HTACCESS")).To(Succeed())
+		})
+
+		it("comments out the mod_php directives", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`    RewriteEngine On
+    # php_value upload_max_filesize 64M (requires mod_php)
+    # php_flag display_errors off (requires mod_php)
+    ExpiresActive On`))
+
+			Expect(buffer.String()).To(ContainSubstring("Compiling .htaccess files into the configuration:"))
+			Expect(buffer.String()).To(ContainSubstring("Warning: line 2: php_value only works with mod_php"))
+		})
+	})
+}
@@ -0,0 +1,41 @@
+package phphttpd
+
+import (
+	"path/filepath"
+)
+
+// IncludeHooks are the globs of the user-provided configuration files that
+// are included at fixed points of the generated configuration, when the
+// directory of the hook exists in .httpd.conf.d:
+//
+//	pre/        after the modules, the MPM settings and the server defaults,
+//	            which it can change, before any other directive
+//	directory/  inside the <Directory> section of the web directory
+//	post/       after all other directives except the health check and status
+//	            endpoints, which it must not restrict
+type IncludeHooks struct {
+	Pre       string
+	Directory string
+	Post      string
+}
+
+func loadIncludeHooks(workingDir string) (IncludeHooks, error) {
+	var hooks IncludeHooks
+	for name, glob := range map[string]*string{
+		"pre":       &hooks.Pre,
+		"directory": &hooks.Directory,
+		"post":      &hooks.Post,
+	} {
+		dir := filepath.Join(workingDir, ".httpd.conf.d", name)
+		exists, err := isDirectory(dir)
+		if err != nil {
+			return IncludeHooks{}, err
+		}
+
+		if exists {
+			*glob = filepath.Join(dir, "*.conf")
+		}
+	}
+
+	return hooks, nil
+}
@@ -0,0 +1,109 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testIncludeHooks(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+
+		config = phphttpd.NewConfig(scribe.NewEmitter(bytes.NewBuffer(nil)))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("does not include any hooks by default", func() {
+		metadata, err := config.Write(layerDir, workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(metadata.ConfigPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).NotTo(ContainSubstring("IncludeOptional"))
+	})
+
+	context("when the hook directories exist", func() {
+		it.Before(func() {
+			for _, hook := range []string{"pre", "directory", "post"} {
+				Expect(os.MkdirAll(filepath.Join(workingDir, ".httpd.conf.d", hook), os.ModePerm)).To(Succeed())
+			}
+		})
+
+		it("includes each hook at its insertion point", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			pre := "IncludeOptional \"" + filepath.Join(workingDir, ".httpd.conf.d", "pre", "*.conf") + "\""
+			directory := "IncludeOptional \"" + filepath.Join(workingDir, ".httpd.conf.d", "directory", "*.conf") + "\""
+			user := "IncludeOptional \"" + filepath.Join(workingDir, ".httpd.conf.d", "*.conf") + "\""
+			post := "IncludeOptional \"" + filepath.Join(workingDir, ".httpd.conf.d", "post", "*.conf") + "\""
+
+			Expect(string(contents)).To(ContainSubstring(`
+# User-provided configuration that comes before the buildpack's own
+` + pre))
+			Expect(strings.Index(string(contents), "MaxRequestWorkers")).To(BeNumerically("<", strings.Index(string(contents), pre)))
+			Expect(strings.Index(string(contents), "\nTimeout 60\n")).To(BeNumerically("<", strings.Index(string(contents), pre)))
+			Expect(string(contents)).To(ContainSubstring(`    Require all granted
+    ` + directory + `
+</Directory>`))
+			Expect(string(contents)).To(HaveSuffix(`
+# User-provided configuration that comes after the buildpack's own
+` + post + "\n"))
+
+			Expect(strings.Index(string(contents), pre)).To(BeNumerically("<", strings.Index(string(contents), "<Directory />")))
+			Expect(strings.Index(string(contents), user)).To(BeNumerically("<", strings.Index(string(contents), post)))
+		})
+
+		context("when the health check is enabled", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_HEALTHCHECK_PATH", "/healthz")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_HEALTHCHECK_PATH")).To(Succeed())
+			})
+
+			it("includes post/ before the health check so that it cannot restrict it", func() {
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+
+				post := "IncludeOptional \"" + filepath.Join(workingDir, ".httpd.conf.d", "post", "*.conf") + "\""
+				Expect(string(contents)).To(ContainSubstring(post))
+				Expect(strings.Index(string(contents), post)).To(BeNumerically("<", strings.Index(string(contents), `<Location "/healthz">`)))
+			})
+		})
+	})
+}
@@ -12,5 +12,32 @@ func TestUnitPhpHttpd(t *testing.T) {
 	suite("Build", testBuild)
 	suite("Detect", testDetect, spec.Sequential())
 	suite("Config", testConfig, spec.Sequential())
+	suite("HealthCheck", testHealthCheck, spec.Sequential())
+	suite("Status", testStatus, spec.Sequential())
+	suite("Caching", testCaching, spec.Sequential())
+	suite("Compression", testCompression, spec.Sequential())
+	suite("ErrorPages", testErrorPages, spec.Sequential())
+	suite("Maintenance", testMaintenance, spec.Sequential())
+	suite("CORS", testCORS, spec.Sequential())
+	suite("AccessControl", testAccessControl, spec.Sequential())
+	suite("Limits", testLimits, spec.Sequential())
+	suite("VirtualHosts", testVirtualHosts, spec.Sequential())
+	suite("ConfigFile", testConfigFile, spec.Sequential())
+	suite("ResponseHeaders", testResponseHeaders, spec.Sequential())
+	suite("Modules", testModules, spec.Sequential())
+	suite("Diagnostics", testDiagnostics, spec.Sequential())
+	suite("Render", testRender)
+	suite("Metadata", testMetadata, spec.Sequential())
+	suite("FpmSocket", testFpmSocket, spec.Sequential())
+	suite("Validation", testValidation, spec.Sequential())
+	suite("WebDirectory", testWebDirectory, spec.Sequential())
+	suite("PHPExecution", testPHPExecution, spec.Sequential())
+	suite("SensitiveFiles", testSensitiveFiles, spec.Sequential())
+	suite("HiddenFiles", testHiddenFiles, spec.Sequential())
+	suite("Htaccess", testHtaccess, spec.Sequential())
+	suite("HtaccessReport", testHtaccessReport, spec.Sequential())
+	suite("IncludeHooks", testIncludeHooks, spec.Sequential())
+	suite("Snippets", testSnippets, spec.Sequential())
+	suite("Profiles", testProfiles, spec.Sequential())
 	suite.Run(t)
 }
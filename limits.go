@@ -0,0 +1,212 @@
+package phphttpd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const defaultRequestReadTimeout = "header=20-40,MinRate=500 body=20,MinRate=500"
+
+// maxRequestBody is the largest value HTTPD accepts for LimitRequestBody.
+const maxRequestBody = 1<<31 - 1
+
+var requestReadTimeoutPattern = regexp.MustCompile(`^(?:(?:handshake|header|body)=\d+(?:-\d+)?(?:,MinRate=\d+)?)(?: (?:handshake|header|body)=\d+(?:-\d+)?(?:,MinRate=\d+)?)*$`)
+
+// Limits protects HTTPD from oversized requests and runaway clients.
+// RequestBody is -1 when no limit is configured or can be derived from the
+// PHP configuration, and RateLimit is the per-connection bandwidth in KiB/s.
+type Limits struct {
+	RequestBody        int64
+	RequestFields      int
+	RequestFieldSize   int
+	RequestReadTimeout string
+	RateLimit          int
+}
+
+func (c Config) loadLimits(s settings) (Limits, error) {
+	limits := Limits{
+		RequestBody:        -1,
+		RequestReadTimeout: s.get("BP_PHP_HTTPD_REQUEST_READ_TIMEOUT"),
+	}
+
+	phpSettings, err := readPHPIniSettings()
+	if err != nil {
+		return Limits{}, err
+	}
+
+	phpLimit, phpLimitSetting := phpRequestBodyLimit(phpSettings)
+
+	if value, ok := s.lookup("BP_PHP_HTTPD_LIMIT_REQUEST_BODY"); ok {
+		limits.RequestBody, err = parsePHPSize(value)
+		if err != nil {
+			return Limits{}, fmt.Errorf("failed to parse %s: %w", s.describe("BP_PHP_HTTPD_LIMIT_REQUEST_BODY"), err)
+		}
+		if limits.RequestBody > maxRequestBody {
+			return Limits{}, fmt.Errorf("%s must be at most %d bytes: %q", s.describe("BP_PHP_HTTPD_LIMIT_REQUEST_BODY"), maxRequestBody, value)
+		}
+
+		if phpLimit > 0 && (limits.RequestBody == 0 || limits.RequestBody > phpLimit) {
+			c.logger.Subprocess("Warning: %s (%s) allows larger request bodies than PHP's %s (%s)", s.describe("BP_PHP_HTTPD_LIMIT_REQUEST_BODY"), value, phpLimitSetting, phpSettings[phpLimitSetting])
+		} else if limits.RequestBody > 0 && limits.RequestBody < phpLimit {
+			c.logger.Subprocess("Warning: %s (%s) rejects uploads that PHP's %s (%s) would accept", s.describe("BP_PHP_HTTPD_LIMIT_REQUEST_BODY"), value, phpLimitSetting, phpSettings[phpLimitSetting])
+		}
+	} else if phpLimit > maxRequestBody {
+		limits.RequestBody = maxRequestBody
+		c.logger.Subprocess("Warning: PHP's %s (%s) is above the largest request body limit HTTPD supports, limiting request bodies to %d bytes", phpLimitSetting, phpSettings[phpLimitSetting], maxRequestBody)
+	} else if phpLimit > 0 {
+		limits.RequestBody = phpLimit
+		c.logger.Debug.Subprocess(fmt.Sprintf("Request body limit derived from PHP's %s: %s", phpLimitSetting, phpSettings[phpLimitSetting]))
+	}
+
+	limits.RequestFields, err = s.lookupInt("BP_PHP_HTTPD_LIMIT_REQUEST_FIELDS", 100, 0, 32767)
+	if err != nil {
+		return Limits{}, err
+	}
+
+	limits.RequestFieldSize, err = s.lookupInt("BP_PHP_HTTPD_LIMIT_REQUEST_FIELD_SIZE", 8190, 1, 1<<31-1)
+	if err != nil {
+		return Limits{}, err
+	}
+
+	if limits.RequestReadTimeout == "" {
+		limits.RequestReadTimeout = defaultRequestReadTimeout
+	}
+
+	if !requestReadTimeoutPattern.MatchString(limits.RequestReadTimeout) {
+		return Limits{}, fmt.Errorf("%s is not a valid RequestReadTimeout value: %q", s.describe("BP_PHP_HTTPD_REQUEST_READ_TIMEOUT"), limits.RequestReadTimeout)
+	}
+
+	limits.RateLimit, err = s.lookupInt("BP_PHP_HTTPD_RATE_LIMIT", 0, 0, 1<<31-1)
+	if err != nil {
+		return Limits{}, err
+	}
+
+	return limits, nil
+}
+
+// phpRequestBodyLimit returns the largest request body PHP accepts, in bytes,
+// from the larger of post_max_size and upload_max_filesize, and the php.ini
+// setting it comes from. It returns 0 when unknown or unlimited.
+func phpRequestBodyLimit(settings map[string]string) (int64, string) {
+	var limit int64
+	var setting string
+	for _, name := range []string{"post_max_size", "upload_max_filesize"} {
+		value, ok := settings[name]
+		if !ok {
+			continue
+		}
+
+		size, err := parsePHPSize(value)
+		if err != nil {
+			continue
+		}
+
+		if size == 0 {
+			return 0, ""
+		}
+
+		if size > limit {
+			limit, setting = size, name
+		}
+	}
+
+	return limit, setting
+}
+
+// readPHPIniSettings reads the php.ini files that the php-dist buildpack
+// points to with $PHPRC and $PHP_INI_SCAN_DIR, in the order PHP loads them.
+func readPHPIniSettings() (map[string]string, error) {
+	var files []string
+	if phprc := os.Getenv("PHPRC"); phprc != "" {
+		files = append(files, filepath.Join(phprc, "php.ini"))
+	}
+
+	for _, dir := range filepath.SplitList(os.Getenv("PHP_INI_SCAN_DIR")) {
+		if dir == "" {
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(dir, "*.ini"))
+		if err != nil {
+			// untested
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+
+	settings := map[string]string{}
+	for _, file := range files {
+		err := parsePHPIni(file, settings)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return settings, nil
+}
+
+func parsePHPIni(path string, settings map[string]string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "[") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		value, _, _ = strings.Cut(value, ";")
+		settings[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+
+	return scanner.Err()
+}
+
+// parsePHPSize parses a size in bytes using PHP's shorthand notation, such as
+// 8M or 1G.
+func parsePHPSize(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+
+	multiplier := int64(1)
+	if len(value) > 0 {
+		switch value[len(value)-1] {
+		case 'k', 'K':
+			multiplier = 1 << 10
+		case 'm', 'M':
+			multiplier = 1 << 20
+		case 'g', 'G':
+			multiplier = 1 << 30
+		}
+	}
+
+	number := value
+	if multiplier > 1 {
+		number = value[:len(value)-1]
+	}
+
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n < 0 || n > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("%q is not a size in bytes", value)
+	}
+
+	return n * multiplier, nil
+}
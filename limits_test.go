@@ -0,0 +1,265 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testLimits(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		phpIniDir  string
+		buffer     *bytes.Buffer
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+
+		phpIniDir, err = os.MkdirTemp("", "php-ini")
+		Expect(err).NotTo(HaveOccurred())
+
+		buffer = bytes.NewBuffer(nil)
+		config = phphttpd.NewConfig(scribe.NewEmitter(buffer))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+		Expect(os.RemoveAll(phpIniDir)).To(Succeed())
+	})
+
+	it("renders the default limits", func() {
+		metadata, err := config.Write(layerDir, workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(metadata.ConfigPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(ContainSubstring("RequestReadTimeout header=20-40,MinRate=500 body=20,MinRate=500\nLimitRequestFields 100\nLimitRequestFieldSize 8190\n"))
+		Expect(string(contents)).NotTo(ContainSubstring("LimitRequestBody"))
+		Expect(string(contents)).NotTo(ContainSubstring("RATE_LIMIT"))
+	})
+
+	context("when the php-dist buildpack left php.ini settings in the build env", func() {
+		it.Before(func() {
+			Expect(os.MkdirAll(filepath.Join(phpIniDir, "scan"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(phpIniDir, "php.ini"), []byte("[PHP]\nupload_max_filesize = 2M\npost_max_size = 8M\n"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(phpIniDir, "scan", "uploads.ini"), []byte("upload_max_filesize = \"64M\" ; larger uploads\n"), 0644)).To(Succeed())
+
+			Expect(os.Setenv("PHPRC", phpIniDir)).To(Succeed())
+			Expect(os.Setenv("PHP_INI_SCAN_DIR", filepath.Join(phpIniDir, "scan"))).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("PHPRC")).To(Succeed())
+			Expect(os.Unsetenv("PHP_INI_SCAN_DIR")).To(Succeed())
+		})
+
+		it("limits the request body to what PHP accepts", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("LimitRequestBody 67108864\n"))
+		})
+
+		context("when $BP_PHP_HTTPD_LIMIT_REQUEST_BODY is smaller than PHP's limit", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_LIMIT_REQUEST_BODY", "10M")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_LIMIT_REQUEST_BODY")).To(Succeed())
+			})
+
+			it("uses it and warns", func() {
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring("LimitRequestBody 10485760\n"))
+				Expect(buffer.String()).To(ContainSubstring("Warning: $BP_PHP_HTTPD_LIMIT_REQUEST_BODY (10M) rejects uploads that PHP's upload_max_filesize (64M) would accept"))
+			})
+		})
+
+		context("when $BP_PHP_HTTPD_LIMIT_REQUEST_BODY is unlimited", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_LIMIT_REQUEST_BODY", "0")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_LIMIT_REQUEST_BODY")).To(Succeed())
+			})
+
+			it("uses it and warns", func() {
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring("LimitRequestBody 0\n"))
+				Expect(buffer.String()).To(ContainSubstring("Warning: $BP_PHP_HTTPD_LIMIT_REQUEST_BODY (0) allows larger request bodies than PHP's upload_max_filesize (64M)"))
+			})
+		})
+	})
+
+	context("when PHP accepts request bodies of 2G or more", func() {
+		it.Before(func() {
+			Expect(os.Setenv("PHPRC", phpIniDir)).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("PHPRC")).To(Succeed())
+		})
+
+		for _, size := range []string{"2G", "4G", "8192M"} {
+			it("limits the request body to the largest value HTTPD supports and warns for "+size, func() {
+				Expect(os.WriteFile(filepath.Join(phpIniDir, "php.ini"), []byte("post_max_size = "+size+"\nupload_max_filesize = 1G\n"), 0644)).To(Succeed())
+
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring("LimitRequestBody 2147483647\n"))
+				Expect(buffer.String()).To(ContainSubstring("Warning: PHP's post_max_size (" + size + ") is above the largest request body limit HTTPD supports, limiting request bodies to 2147483647 bytes"))
+			})
+		}
+
+		it("does not limit the request body when post_max_size is unlimited", func() {
+			Expect(os.WriteFile(filepath.Join(phpIniDir, "php.ini"), []byte("post_max_size = 0\nupload_max_filesize = 4G\n"), 0644)).To(Succeed())
+
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).NotTo(ContainSubstring("LimitRequestBody"))
+		})
+	})
+
+	context("when the limits are configured", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_LIMIT_REQUEST_BODY", "1048576")).To(Succeed())
+			Expect(os.Setenv("BP_PHP_HTTPD_LIMIT_REQUEST_FIELDS", "50")).To(Succeed())
+			Expect(os.Setenv("BP_PHP_HTTPD_LIMIT_REQUEST_FIELD_SIZE", "16380")).To(Succeed())
+			Expect(os.Setenv("BP_PHP_HTTPD_REQUEST_READ_TIMEOUT", "header=10-20,MinRate=500 body=10")).To(Succeed())
+			Expect(os.Setenv("BP_PHP_HTTPD_RATE_LIMIT", "512")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_LIMIT_REQUEST_BODY")).To(Succeed())
+			Expect(os.Unsetenv("BP_PHP_HTTPD_LIMIT_REQUEST_FIELDS")).To(Succeed())
+			Expect(os.Unsetenv("BP_PHP_HTTPD_LIMIT_REQUEST_FIELD_SIZE")).To(Succeed())
+			Expect(os.Unsetenv("BP_PHP_HTTPD_REQUEST_READ_TIMEOUT")).To(Succeed())
+			Expect(os.Unsetenv("BP_PHP_HTTPD_RATE_LIMIT")).To(Succeed())
+		})
+
+		it("renders them", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("LoadModule ratelimit_module modules/mod_ratelimit.so"))
+			Expect(string(contents)).To(ContainSubstring("RequestReadTimeout header=10-20,MinRate=500 body=10\nLimitRequestFields 50\nLimitRequestFieldSize 16380\nLimitRequestBody 1048576\n"))
+			Expect(string(contents)).To(ContainSubstring("SetOutputFilter RATE_LIMIT\nSetEnv rate-limit 512\n"))
+		})
+	})
+
+	context("failure cases", func() {
+		context("when the request body limit is not a size", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_LIMIT_REQUEST_BODY", "10MB")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_LIMIT_REQUEST_BODY")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`failed to parse $BP_PHP_HTTPD_LIMIT_REQUEST_BODY: "10MB" is not a size in bytes`)))
+			})
+		})
+
+		context("when the request body limit is larger than HTTPD supports", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_LIMIT_REQUEST_BODY", "2G")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_LIMIT_REQUEST_BODY")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(`$BP_PHP_HTTPD_LIMIT_REQUEST_BODY must be at most 2147483647 bytes: "2G"`))
+			})
+		})
+
+		context("when the request fields limit is out of range", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_LIMIT_REQUEST_FIELDS", "40000")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_LIMIT_REQUEST_FIELDS")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`$BP_PHP_HTTPD_LIMIT_REQUEST_FIELDS must be a number between 0 and 32767: "40000"`)))
+			})
+		})
+
+		context("when the request read timeout is malformed", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_REQUEST_READ_TIMEOUT", "header=20\nInclude /etc/passwd")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_REQUEST_READ_TIMEOUT")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("$BP_PHP_HTTPD_REQUEST_READ_TIMEOUT is not a valid RequestReadTimeout value")))
+			})
+		})
+
+		context("when the rate limit is not a number", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_RATE_LIMIT", "fast")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_RATE_LIMIT")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`$BP_PHP_HTTPD_RATE_LIMIT must be a number`)))
+			})
+		})
+	})
+}
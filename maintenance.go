@@ -0,0 +1,136 @@
+package phphttpd
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//go:embed assets/error-pages/maintenance.html
+var defaultMaintenancePage []byte
+
+const (
+	// MaintenanceEnvironmentVariable turns on maintenance mode when it is set
+	// to a true value in the launch environment.
+	MaintenanceEnvironmentVariable = "PHP_HTTPD_MAINTENANCE"
+
+	// MaintenanceResponseFile is the name of the file, written into the
+	// config layer, that HTTPD sends as-is while in maintenance mode.
+	MaintenanceResponseFile = "maintenance.asis"
+
+	maintenanceURL = "/.httpd-maintenance"
+)
+
+// Maintenance describes the rules that answer every request with a 503
+// maintenance page while FlagFile exists or the maintenance environment
+// variable is set at launch. Clients in Allow, and requests carrying the
+// bypass header, still reach the app.
+type Maintenance struct {
+	Enabled      bool
+	FlagFile     string
+	Allow        []string
+	BypassHeader string
+	BypassValue  string
+	ResponseFile string
+}
+
+// EnvironmentVariable returns the name of the launch environment variable
+// that turns on maintenance mode.
+func (m Maintenance) EnvironmentVariable() string {
+	return MaintenanceEnvironmentVariable
+}
+
+// URL returns the URL path the maintenance response is served from.
+func (m Maintenance) URL() string {
+	return maintenanceURL
+}
+
+func loadMaintenance(s settings, layerPath, workingDir, webDir string) (Maintenance, error) {
+	enabled, err := s.lookupBool("BP_PHP_HTTPD_ENABLE_MAINTENANCE_MODE", false)
+	if err != nil {
+		return Maintenance{}, err
+	}
+
+	if !enabled {
+		return Maintenance{}, nil
+	}
+
+	maintenance := Maintenance{
+		Enabled:  true,
+		FlagFile: s.get("BP_PHP_HTTPD_MAINTENANCE_FILE"),
+		Allow:    s.lookupList("BP_PHP_HTTPD_MAINTENANCE_ALLOW"),
+	}
+
+	if maintenance.FlagFile == "" {
+		maintenance.FlagFile = "/tmp/maintenance"
+	}
+
+	if !filepath.IsAbs(maintenance.FlagFile) || strings.ContainsAny(maintenance.FlagFile, " \t\"'") {
+		return Maintenance{}, fmt.Errorf("%s must be an absolute path without whitespace or quotes: %q", s.describe("BP_PHP_HTTPD_MAINTENANCE_FILE"), maintenance.FlagFile)
+	}
+
+	for _, allow := range maintenance.Allow {
+		if !isIPOrCIDR(allow) {
+			return Maintenance{}, fmt.Errorf("%s contains an invalid IP address or CIDR: %q", s.describe("BP_PHP_HTTPD_MAINTENANCE_ALLOW"), allow)
+		}
+	}
+
+	if bypass, ok := s.lookup("BP_PHP_HTTPD_MAINTENANCE_BYPASS_HEADER"); ok {
+		header, value, _ := strings.Cut(bypass, "=")
+		header, value = strings.TrimSpace(header), strings.TrimSpace(value)
+		if !isHeaderName(header) || value == "" || strings.ContainsAny(value, " \t\"") {
+			return Maintenance{}, fmt.Errorf("%s must be of the form Header-Name=value: %q", s.describe("BP_PHP_HTTPD_MAINTENANCE_BYPASS_HEADER"), bypass)
+		}
+		maintenance.BypassHeader, maintenance.BypassValue = textproto.CanonicalMIMEHeaderKey(header), value
+	}
+
+	retryAfter, err := s.lookupInt("BP_PHP_HTTPD_MAINTENANCE_RETRY_AFTER", 300, 0, 1<<31-1)
+	if err != nil {
+		return Maintenance{}, err
+	}
+
+	page := defaultMaintenancePage
+	if value, ok := s.lookup("BP_PHP_HTTPD_MAINTENANCE_PAGE"); ok {
+		file, ok := cleanPagePath(value)
+		if !ok {
+			return Maintenance{}, fmt.Errorf("invalid %s: %q", s.describe("BP_PHP_HTTPD_MAINTENANCE_PAGE"), value)
+		}
+
+		page, err = os.ReadFile(filepath.Join(workingDir, pageLocation(webDir, file)))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return Maintenance{}, fmt.Errorf("maintenance page does not exist: %s", pageLocation(webDir, file))
+			}
+			// untested
+			return Maintenance{}, err
+		}
+	}
+
+	response := fmt.Sprintf("Status: 503 Service Unavailable\nRetry-After: %d\nCache-Control: no-store\nContent-Type: text/html; charset=utf-8\n\n", retryAfter)
+
+	maintenance.ResponseFile = filepath.Join(layerPath, MaintenanceResponseFile)
+	err = os.WriteFile(maintenance.ResponseFile, append([]byte(response), page...), 0644)
+	if err != nil {
+		return Maintenance{}, fmt.Errorf("failed to write maintenance response: %w", err)
+	}
+
+	return maintenance, nil
+}
+
+func isHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,226 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testMaintenance(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+
+		config = phphttpd.NewConfig(scribe.NewEmitter(bytes.NewBuffer(nil)))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("does not render maintenance rules by default", func() {
+		metadata, err := config.Write(layerDir, workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(metadata.ConfigPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).NotTo(ContainSubstring("asis_module"))
+		Expect(string(contents)).NotTo(ContainSubstring("Maintenance mode"))
+		Expect(filepath.Join(layerDir, phphttpd.MaintenanceResponseFile)).NotTo(BeAnExistingFile())
+	})
+
+	context("when $BP_PHP_HTTPD_ENABLE_MAINTENANCE_MODE is true", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_ENABLE_MAINTENANCE_MODE", "true")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_ENABLE_MAINTENANCE_MODE")).To(Succeed())
+		})
+
+		it("serves the built-in maintenance page with a 503 when the flag file exists or the env var is set", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			responseFile := filepath.Join(layerDir, phphttpd.MaintenanceResponseFile)
+			response, err := os.ReadFile(responseFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(response)).To(HavePrefix("Status: 503 Service Unavailable\nRetry-After: 300\n"))
+			Expect(string(response)).To(ContainSubstring("Down for maintenance"))
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("LoadModule alias_module modules/mod_alias.so"))
+			Expect(string(contents)).To(ContainSubstring("LoadModule asis_module modules/mod_asis.so"))
+			Expect(string(contents)).To(ContainSubstring(`RewriteEngine On
+RewriteCond "/tmp/maintenance" -f [OR]
+RewriteCond %{ENV:PHP_HTTPD_MAINTENANCE} ^(1|t|true|on|yes)$ [NC]
+RewriteRule ^ /.httpd-maintenance [PT,L]`))
+			Expect(string(contents)).To(ContainSubstring(`Alias "/.httpd-maintenance" "` + responseFile + `"
+<Location "/.httpd-maintenance">
+    SetHandler send-as-is
+    Require all granted
+</Location>`))
+		})
+
+		context("when the maintenance mode is fully configured", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "htdocs", "maintenance.html"), []byte("custom page"), 0644)).To(Succeed())
+
+				Expect(os.Setenv("BP_PHP_HTTPD_MAINTENANCE_FILE", "/workspace/storage/down")).To(Succeed())
+				Expect(os.Setenv("BP_PHP_HTTPD_MAINTENANCE_ALLOW", "10.0.0.0/8,192.168.1.1")).To(Succeed())
+				Expect(os.Setenv("BP_PHP_HTTPD_MAINTENANCE_BYPASS_HEADER", "x-maintenance-bypass=s3cret")).To(Succeed())
+				Expect(os.Setenv("BP_PHP_HTTPD_MAINTENANCE_RETRY_AFTER", "60")).To(Succeed())
+				Expect(os.Setenv("BP_PHP_HTTPD_MAINTENANCE_PAGE", "maintenance.html")).To(Succeed())
+				Expect(os.Setenv("BP_PHP_HTTPD_HEALTHCHECK_PATH", "/healthz")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_MAINTENANCE_FILE")).To(Succeed())
+				Expect(os.Unsetenv("BP_PHP_HTTPD_MAINTENANCE_ALLOW")).To(Succeed())
+				Expect(os.Unsetenv("BP_PHP_HTTPD_MAINTENANCE_BYPASS_HEADER")).To(Succeed())
+				Expect(os.Unsetenv("BP_PHP_HTTPD_MAINTENANCE_RETRY_AFTER")).To(Succeed())
+				Expect(os.Unsetenv("BP_PHP_HTTPD_MAINTENANCE_PAGE")).To(Succeed())
+				Expect(os.Unsetenv("BP_PHP_HTTPD_HEALTHCHECK_PATH")).To(Succeed())
+			})
+
+			it("exempts the allowed clients, bypass header and health check", func() {
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				response, err := os.ReadFile(filepath.Join(layerDir, phphttpd.MaintenanceResponseFile))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(response)).To(HavePrefix("Status: 503 Service Unavailable\nRetry-After: 60\n"))
+				Expect(string(response)).To(HaveSuffix("\n\ncustom page"))
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring(`RewriteCond "/workspace/storage/down" -f [OR]
+RewriteCond %{ENV:PHP_HTTPD_MAINTENANCE} ^(1|t|true|on|yes)$ [NC]
+RewriteCond %{REQUEST_URI} !=/healthz
+RewriteCond expr "! -R '10.0.0.0/8'"
+RewriteCond expr "! -R '192.168.1.1'"
+RewriteCond %{HTTP:X-Maintenance-Bypass} !=s3cret
+RewriteRule ^ /.httpd-maintenance [PT,L]`))
+			})
+		})
+	})
+
+	context("failure cases", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_ENABLE_MAINTENANCE_MODE", "true")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_ENABLE_MAINTENANCE_MODE")).To(Succeed())
+		})
+
+		context("when $BP_PHP_HTTPD_ENABLE_MAINTENANCE_MODE cannot be parsed into a bool", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_ENABLE_MAINTENANCE_MODE", "blah")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse $BP_PHP_HTTPD_ENABLE_MAINTENANCE_MODE into boolean")))
+			})
+		})
+
+		context("when the flag file is not an absolute path", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_MAINTENANCE_FILE", "maintenance")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_MAINTENANCE_FILE")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("$BP_PHP_HTTPD_MAINTENANCE_FILE must be an absolute path")))
+			})
+		})
+
+		context("when an allowed address is invalid", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_MAINTENANCE_ALLOW", "office")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_MAINTENANCE_ALLOW")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`$BP_PHP_HTTPD_MAINTENANCE_ALLOW contains an invalid IP address or CIDR: "office"`)))
+			})
+		})
+
+		context("when the bypass header is malformed", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_MAINTENANCE_BYPASS_HEADER", "X-Bypass")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_MAINTENANCE_BYPASS_HEADER")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("$BP_PHP_HTTPD_MAINTENANCE_BYPASS_HEADER must be of the form Header-Name=value")))
+			})
+		})
+
+		context("when the retry after value is invalid", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_MAINTENANCE_RETRY_AFTER", "soon")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_MAINTENANCE_RETRY_AFTER")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`$BP_PHP_HTTPD_MAINTENANCE_RETRY_AFTER must be a number`)))
+			})
+		})
+
+		context("when the maintenance page does not exist", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_MAINTENANCE_PAGE", ".httpd-errors/maintenance.html")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_MAINTENANCE_PAGE")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("maintenance page does not exist: .httpd-errors/maintenance.html")))
+			})
+		})
+	})
+}
@@ -0,0 +1,216 @@
+package phphttpd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// MetadataFile is the name of the file, written into the config layer, that
+// describes the generated configuration for other buildpacks and tooling.
+const MetadataFile = "php-httpd.json"
+
+var loadModulePattern = regexp.MustCompile(`(?m)^LoadModule (\w+)_module `)
+
+// Metadata is a machine-readable summary of the generated configuration.
+type Metadata struct {
+	ConfigPath     string                `json:"config_path"`
+	AppRoot        string                `json:"app_root"`
+	WebDirectory   string                `json:"web_directory"`
+	DocumentRoot   string                `json:"document_root"`
+	FpmSocket      string                `json:"fpm_socket"`
+	HTTPSRedirect  bool                  `json:"https_redirect"`
+	Modules        []string              `json:"modules"`
+	HealthCheck    *HealthCheckMetadata  `json:"health_check,omitempty"`
+	Status         *StatusMetadata       `json:"status,omitempty"`
+	Maintenance    *MaintenanceMetadata  `json:"maintenance,omitempty"`
+	VirtualHosts   []VirtualHostMetadata `json:"virtual_hosts,omitempty"`
+	Mounts         []MountMetadata       `json:"mounts,omitempty"`
+	UserInclude    string                `json:"user_include,omitempty"`
+	Profile        string                `json:"profile,omitempty"`
+	Assets         []string              `json:"assets,omitempty"`
+	RequestBodyMax int64                 `json:"request_body_max,omitempty"`
+}
+
+type HealthCheckMetadata struct {
+	Path        string `json:"path"`
+	FpmPingPath string `json:"fpm_ping_path,omitempty"`
+}
+
+type StatusMetadata struct {
+	Path          string `json:"path"`
+	FpmStatusPath string `json:"fpm_status_path,omitempty"`
+	Port          int    `json:"port,omitempty"`
+}
+
+type MaintenanceMetadata struct {
+	FlagFile            string `json:"flag_file"`
+	EnvironmentVariable string `json:"environment_variable"`
+}
+
+type VirtualHostMetadata struct {
+	ServerName    string   `json:"server_name"`
+	ServerAliases []string `json:"server_aliases,omitempty"`
+	DocumentRoot  string   `json:"document_root"`
+	FpmSocket     string   `json:"fpm_socket"`
+}
+
+type MountMetadata struct {
+	Path         string `json:"path"`
+	DocumentRoot string `json:"document_root"`
+	FpmSocket    string `json:"fpm_socket"`
+}
+
+func newMetadata(data HttpdConfig, configPath string, rendered []byte) Metadata {
+	metadata := Metadata{
+		ConfigPath:    configPath,
+		AppRoot:       data.AppRoot,
+		WebDirectory:  data.WebDirectory,
+		DocumentRoot:  data.DocumentRoot(),
+		FpmSocket:     data.FpmSocket,
+		HTTPSRedirect: !data.DisableHTTPSRedirect,
+		UserInclude:   data.UserInclude,
+		Profile:       data.Profile,
+	}
+
+	for _, match := range loadModulePattern.FindAllSubmatch(rendered, -1) {
+		metadata.Modules = append(metadata.Modules, string(match[1]))
+	}
+
+	if data.HealthCheck.Path != "" {
+		metadata.HealthCheck = &HealthCheckMetadata{
+			Path:        data.HealthCheck.Path,
+			FpmPingPath: data.HealthCheck.FpmPingPath,
+		}
+	}
+
+	if data.Status.Enabled {
+		metadata.Status = &StatusMetadata{
+			Path:          data.Status.Path,
+			FpmStatusPath: data.Status.FpmStatusPath,
+			Port:          data.Status.Port,
+		}
+	}
+
+	if data.Maintenance.Enabled {
+		metadata.Maintenance = &MaintenanceMetadata{
+			FlagFile:            data.Maintenance.FlagFile,
+			EnvironmentVariable: data.Maintenance.EnvironmentVariable(),
+		}
+	}
+
+	for _, host := range data.VirtualHosts {
+		metadata.VirtualHosts = append(metadata.VirtualHosts, VirtualHostMetadata{
+			ServerName:    host.ServerName,
+			ServerAliases: host.ServerAliases,
+			DocumentRoot:  host.Root,
+			FpmSocket:     host.FpmSocket,
+		})
+	}
+
+	for _, mount := range data.Mounts {
+		metadata.Mounts = append(metadata.Mounts, MountMetadata{
+			Path:         mount.Path,
+			DocumentRoot: mount.Root,
+			FpmSocket:    mount.FpmSocket,
+		})
+	}
+
+	if data.HealthCheck.ResponseFile != "" {
+		metadata.Assets = append(metadata.Assets, data.HealthCheck.ResponseFile)
+	}
+
+	if data.ErrorPages.Directory != "" {
+		pages, _ := filepath.Glob(filepath.Join(data.ErrorPages.Directory, "*"))
+		metadata.Assets = append(metadata.Assets, pages...)
+	}
+
+	if data.Maintenance.ResponseFile != "" {
+		metadata.Assets = append(metadata.Assets, data.Maintenance.ResponseFile)
+	}
+
+	metadata.Assets = append(metadata.Assets, data.Snippets...)
+
+	if data.Limits.RequestBody > 0 {
+		metadata.RequestBodyMax = data.Limits.RequestBody
+	}
+
+	return metadata
+}
+
+func writeMetadata(path string, metadata Metadata) error {
+	content, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		// untested
+		return fmt.Errorf("failed to encode configuration metadata: %w", err)
+	}
+
+	err = os.WriteFile(path, append(content, '\n'), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write configuration metadata: %w", err)
+	}
+
+	return nil
+}
+
+// ReadMetadata reads the metadata file written alongside the configuration
+// in the given layer.
+func ReadMetadata(layerPath string) (Metadata, error) {
+	content, err := os.ReadFile(filepath.Join(layerPath, MetadataFile))
+	if err != nil {
+		return Metadata{}, fmt.Errorf("failed to read configuration metadata: %w", err)
+	}
+
+	var metadata Metadata
+	err = json.Unmarshal(content, &metadata)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("failed to parse configuration metadata: %w", err)
+	}
+
+	return metadata, nil
+}
+
+// LayerMetadata returns the summary as layer metadata, which is stored as
+// TOML and therefore cannot hold empty values.
+func (m Metadata) LayerMetadata() map[string]interface{} {
+	metadata := map[string]interface{}{
+		"config_path":    m.ConfigPath,
+		"web_directory":  m.WebDirectory,
+		"document_root":  m.DocumentRoot,
+		"fpm_socket":     m.FpmSocket,
+		"https_redirect": m.HTTPSRedirect,
+		"modules":        m.Modules,
+	}
+
+	if m.HealthCheck != nil {
+		metadata["health_check_path"] = m.HealthCheck.Path
+	}
+
+	if m.Status != nil {
+		metadata["status_path"] = m.Status.Path
+	}
+
+	if m.Maintenance != nil {
+		metadata["maintenance_flag_file"] = m.Maintenance.FlagFile
+	}
+
+	var hosts []string
+	for _, host := range m.VirtualHosts {
+		hosts = append(hosts, host.ServerName)
+	}
+	if len(hosts) > 0 {
+		metadata["virtual_hosts"] = hosts
+	}
+
+	var mounts []string
+	for _, mount := range m.Mounts {
+		mounts = append(mounts, mount.Path)
+	}
+	if len(mounts) > 0 {
+		metadata["mounts"] = mounts
+	}
+
+	return metadata
+}
@@ -0,0 +1,121 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testMetadata(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+
+		config = phphttpd.NewConfig(scribe.NewEmitter(bytes.NewBuffer(nil)))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("writes a summary of the configuration into the layer", func() {
+		metadata, err := config.Write(layerDir, workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		path := filepath.Join(layerDir, "httpd.conf")
+		Expect(metadata.ConfigPath).To(Equal(path))
+		Expect(metadata.AppRoot).To(Equal(workingDir))
+		Expect(metadata.WebDirectory).To(Equal("htdocs"))
+		Expect(metadata.DocumentRoot).To(Equal(filepath.Join(workingDir, "htdocs")))
+		Expect(metadata.FpmSocket).To(Equal("127.0.0.1:9000"))
+		Expect(metadata.HTTPSRedirect).To(BeTrue())
+		Expect(metadata.Modules).To(ContainElements("proxy_fcgi", "rewrite", "headers"))
+		Expect(metadata.Modules).NotTo(ContainElement("status"))
+		Expect(metadata.HealthCheck).To(BeNil())
+		Expect(metadata.Status).To(BeNil())
+		Expect(metadata.Maintenance).To(BeNil())
+
+		Expect(metadata.LayerMetadata()).To(Equal(map[string]interface{}{
+			"config_path":    path,
+			"web_directory":  "htdocs",
+			"document_root":  filepath.Join(workingDir, "htdocs"),
+			"fpm_socket":     "127.0.0.1:9000",
+			"https_redirect": true,
+			"modules":        metadata.Modules,
+		}))
+
+		written, err := phphttpd.ReadMetadata(layerDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(written).To(Equal(metadata))
+	})
+
+	context("when optional endpoints are enabled", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_HEALTHCHECK_PATH", "/healthz")).To(Succeed())
+			Expect(os.Setenv("BP_PHP_HTTPD_ENABLE_STATUS", "true")).To(Succeed())
+			Expect(os.Setenv("BP_PHP_HTTPD_STATUS_PORT", "9102")).To(Succeed())
+			Expect(os.Setenv("BP_PHP_HTTPD_ENABLE_MAINTENANCE_MODE", "true")).To(Succeed())
+			Expect(os.Setenv("BP_PHP_ENABLE_HTTPS_REDIRECT", "false")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_HEALTHCHECK_PATH")).To(Succeed())
+			Expect(os.Unsetenv("BP_PHP_HTTPD_ENABLE_STATUS")).To(Succeed())
+			Expect(os.Unsetenv("BP_PHP_HTTPD_STATUS_PORT")).To(Succeed())
+			Expect(os.Unsetenv("BP_PHP_HTTPD_ENABLE_MAINTENANCE_MODE")).To(Succeed())
+			Expect(os.Unsetenv("BP_PHP_ENABLE_HTTPS_REDIRECT")).To(Succeed())
+		})
+
+		it("describes them", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metadata.HTTPSRedirect).To(BeFalse())
+			Expect(metadata.Modules).To(ContainElements("status", "asis"))
+			Expect(metadata.HealthCheck).To(Equal(&phphttpd.HealthCheckMetadata{Path: "/healthz"}))
+			Expect(metadata.Status).To(Equal(&phphttpd.StatusMetadata{Path: "/server-status", Port: 9102}))
+			Expect(metadata.Maintenance).To(Equal(&phphttpd.MaintenanceMetadata{
+				FlagFile:            "/tmp/maintenance",
+				EnvironmentVariable: "PHP_HTTPD_MAINTENANCE",
+			}))
+
+			layerMetadata := metadata.LayerMetadata()
+			Expect(layerMetadata).To(HaveKeyWithValue("health_check_path", "/healthz"))
+			Expect(layerMetadata).To(HaveKeyWithValue("status_path", "/server-status"))
+			Expect(layerMetadata).To(HaveKeyWithValue("maintenance_flag_file", "/tmp/maintenance"))
+		})
+	})
+
+	context("failure cases", func() {
+		context("when the metadata file is malformed", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(layerDir, phphttpd.MetadataFile), []byte("{"), 0644)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := phphttpd.ReadMetadata(layerDir)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse configuration metadata")))
+			})
+		})
+	})
+}
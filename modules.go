@@ -0,0 +1,21 @@
+package phphttpd
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var moduleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// loadModules returns the names of the additional modules to load, such as
+// "proxy_http" for mod_proxy_http.
+func loadModules(s settings) ([]string, error) {
+	modules := s.lookupList("BP_PHP_HTTPD_MODULES")
+	for _, module := range modules {
+		if !moduleNamePattern.MatchString(module) {
+			return nil, fmt.Errorf("%s contains an invalid module name: %q", s.describe("BP_PHP_HTTPD_MODULES"), module)
+		}
+	}
+
+	return modules, nil
+}
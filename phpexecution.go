@@ -0,0 +1,76 @@
+package phphttpd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// noPHPPreset lists the directories that a framework lets users upload files
+// to. It applies when its marker, relative to the web directory, exists.
+type noPHPPreset struct {
+	Framework string
+	Marker    string
+	Paths     []string
+}
+
+// noPHPPresets are enabled by $BP_PHP_HTTPD_ENABLE_NO_PHP_PRESETS.
+var noPHPPresets = []noPHPPreset{
+	{Framework: "WordPress", Marker: "wp-includes/version.php", Paths: []string{"wp-content/uploads"}},
+	{Framework: "Drupal", Marker: "core/lib/Drupal.php", Paths: []string{"sites/default/files"}},
+	{Framework: "Joomla", Marker: "libraries/src/Version.php", Paths: []string{"images", "media"}},
+	{Framework: "Laravel", Marker: "../artisan", Paths: []string{"storage"}},
+}
+
+// NoPHPDirectories returns the absolute paths of the directories in which
+// PHP files are never executed.
+func (h HttpdConfig) NoPHPDirectories() []string {
+	var directories []string
+	for _, p := range h.NoPHPPaths {
+		directories = append(directories, filepath.Join(h.DocumentRoot(), p))
+	}
+	return directories
+}
+
+// loadNoPHPPaths returns the directories, relative to the web directory, in
+// which PHP execution is forbidden: the ones of the frameworks detected in
+// the web directory followed by the configured ones.
+func (c Config) loadNoPHPPaths(s settings, workingDir, webDir string) ([]string, error) {
+	enablePresets, err := s.lookupBool("BP_PHP_HTTPD_ENABLE_NO_PHP_PRESETS", true)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	if enablePresets {
+		root := filepath.Join(workingDir, webDir)
+		for _, preset := range noPHPPresets {
+			marker := filepath.Join(root, filepath.FromSlash(preset.Marker))
+			if !strings.HasPrefix(marker, workingDir+string(filepath.Separator)) {
+				continue
+			}
+
+			_, err := os.Stat(marker)
+			if err != nil {
+				continue
+			}
+
+			c.logger.Debug.Subprocess(fmt.Sprintf("Detected %s, denying PHP execution in %s", preset.Framework, strings.Join(preset.Paths, " ")))
+			for _, p := range preset.Paths {
+				paths = appendUnique(paths, p)
+			}
+		}
+	}
+
+	for _, p := range s.lookupList("BP_PHP_HTTPD_NO_PHP_PATHS") {
+		trimmed := strings.Trim(p, "/")
+		if !isAppPath(trimmed) || path.Clean(trimmed) == "." {
+			return nil, fmt.Errorf("%s entries must be directories inside the web directory: %q", s.describe("BP_PHP_HTTPD_NO_PHP_PATHS"), p)
+		}
+		paths = appendUnique(paths, path.Clean(trimmed))
+	}
+
+	return paths, nil
+}
@@ -0,0 +1,162 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testPHPExecution(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+
+		config = phphttpd.NewConfig(scribe.NewEmitter(bytes.NewBuffer(nil)))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("does not restrict PHP execution by default", func() {
+		metadata, err := config.Write(layerDir, workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(metadata.ConfigPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).NotTo(ContainSubstring("Never execute PHP files"))
+	})
+
+	context("when the web directory contains a WordPress installation", func() {
+		it.Before(func() {
+			Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs", "wp-includes"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "htdocs", "wp-includes", "version.php"), nil, 0600)).To(Succeed())
+		})
+
+		it("denies PHP execution in the uploads directory", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`<Directory "` + filepath.Join(workingDir, "htdocs", "wp-content", "uploads") + `">
+  <FilesMatch "(?i)\.(php\d*|phar|phtml|pht)(\.|$)">
+      Require all denied
+  </FilesMatch>
+</Directory>`))
+		})
+
+		context("when $BP_PHP_HTTPD_ENABLE_NO_PHP_PRESETS is false", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_ENABLE_NO_PHP_PRESETS", "false")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_ENABLE_NO_PHP_PRESETS")).To(Succeed())
+			})
+
+			it("does not apply the preset", func() {
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).NotTo(ContainSubstring("wp-content"))
+			})
+		})
+	})
+
+	context("when the application is a Laravel project", func() {
+		it.Before(func() {
+			Expect(os.Mkdir(filepath.Join(workingDir, "public"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "artisan"), nil, 0600)).To(Succeed())
+			Expect(os.Setenv("BP_PHP_WEB_DIR", "public")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_WEB_DIR")).To(Succeed())
+		})
+
+		it("denies PHP execution in the public storage link", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`<Directory "` + filepath.Join(workingDir, "public", "storage") + `">`))
+		})
+	})
+
+	context("when $BP_PHP_HTTPD_NO_PHP_PATHS is set", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_NO_PHP_PATHS", "/uploads/, files/cache")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_NO_PHP_PATHS")).To(Succeed())
+		})
+
+		it("denies PHP execution in each directory", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`<Directory "` + filepath.Join(workingDir, "htdocs", "uploads") + `">`))
+			Expect(string(contents)).To(ContainSubstring(`<Directory "` + filepath.Join(workingDir, "htdocs", "files", "cache") + `">`))
+		})
+	})
+
+	context("failure cases", func() {
+		context("when a path leaves the web directory", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_NO_PHP_PATHS", "uploads/../..")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_NO_PHP_PATHS")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(`$BP_PHP_HTTPD_NO_PHP_PATHS entries must be directories inside the web directory: "uploads/../.."`))
+			})
+		})
+
+		context("when $BP_PHP_HTTPD_ENABLE_NO_PHP_PRESETS is not a boolean", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_ENABLE_NO_PHP_PRESETS", "sometimes")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_ENABLE_NO_PHP_PRESETS")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse $BP_PHP_HTTPD_ENABLE_NO_PHP_PRESETS into boolean")))
+			})
+		})
+	})
+}
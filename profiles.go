@@ -0,0 +1,71 @@
+package phphttpd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var profileName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// loadProfile returns the configuration profile selected by
+// $BP_PHP_HTTPD_PROFILE, or the empty string. It can only be set in the
+// environment, since it selects which parts of the configuration files apply.
+func loadProfile() (string, error) {
+	profile := os.Getenv("BP_PHP_HTTPD_PROFILE")
+	if profile != "" && (!profileName.MatchString(profile) || strings.Contains(profile, "..")) {
+		return "", fmt.Errorf("$BP_PHP_HTTPD_PROFILE must only contain letters, digits, '.', '-' and '_': %q", profile)
+	}
+
+	return profile, nil
+}
+
+// loadProfileInclude logs what the selected profile changes and returns the
+// glob of its configuration files in .httpd.conf.d/profiles/<name>, if that
+// directory exists.
+func (c Config) loadProfileInclude(s settings, workingDir, profile string) (string, error) {
+	if profile == "" {
+		return "", nil
+	}
+
+	c.logger.Subprocess("Profile: %s", profile)
+
+	var overrides []string
+	for _, value := range s {
+		if strings.HasPrefix(value.Key, "profiles."+profile+".") {
+			overrides = append(overrides, fmt.Sprintf("%s (%s, line %d)", value.Key, value.File, value.Line))
+		}
+	}
+	sort.Strings(overrides)
+	for _, override := range overrides {
+		c.logger.Action("Overrides %s", override)
+	}
+
+	dir := filepath.Join(workingDir, ".httpd.conf.d", "profiles", profile)
+	exists, err := isDirectory(dir)
+	if err != nil {
+		return "", err
+	}
+
+	if !exists {
+		if len(overrides) == 0 {
+			c.logger.Subprocess("Warning: profile %s has no settings in %s or %s and no .httpd.conf.d/profiles/%s directory", profile, ConfigFile, ProjectFile, profile)
+		}
+		return "", nil
+	}
+
+	include := filepath.Join(dir, "*.conf")
+	files, err := filepath.Glob(include)
+	if err != nil {
+		// untested
+		return "", err
+	}
+	for _, file := range files {
+		c.logger.Action("Includes %s", relativeSnippetPath(workingDir, file))
+	}
+
+	return include, nil
+}
@@ -0,0 +1,134 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testProfiles(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		buffer     *bytes.Buffer
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+
+		Expect(os.MkdirAll(filepath.Join(workingDir, ".httpd.conf.d", "profiles", "staging"), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.conf.d", "profiles", "staging", "auth.conf"), []byte("Header set X-Robots-Tag noindex\n"), 0600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.conf.d", "profiles", "staging", "auth.conf.orig"), []byte("Header set X-Robots-Tag all\n"), 0600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.toml"), []byte(`[compression]
+level = 4
+
+[profiles.staging.compression]
+level = 1
+`), 0600)).To(Succeed())
+
+		buffer = bytes.NewBuffer(nil)
+		config = phphttpd.NewConfig(scribe.NewEmitter(buffer))
+	})
+
+	it.After(func() {
+		Expect(os.Unsetenv("BP_PHP_HTTPD_PROFILE")).To(Succeed())
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("ignores profiles unless one is selected", func() {
+		metadata, err := config.Write(layerDir, workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(metadata.ConfigPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(ContainSubstring("DeflateCompressionLevel 4"))
+		Expect(string(contents)).NotTo(ContainSubstring("profiles"))
+		Expect(buffer.String()).NotTo(ContainSubstring("Profile:"))
+	})
+
+	context("when $BP_PHP_HTTPD_PROFILE is set", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_PROFILE", "staging")).To(Succeed())
+		})
+
+		it("applies the profile's settings and includes its directory", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("DeflateCompressionLevel 1"))
+			Expect(string(contents)).To(ContainSubstring("IncludeOptional \"" + filepath.Join(workingDir, ".httpd.conf.d", "profiles", "staging", "*.conf") + "\""))
+
+			Expect(buffer.String()).To(ContainSubstring("Profile: staging"))
+			Expect(buffer.String()).To(ContainSubstring("Overrides profiles.staging.compression.level (.httpd.toml, line 5)"))
+			Expect(buffer.String()).To(ContainSubstring("Includes .httpd.conf.d/profiles/staging/auth.conf\n"))
+			Expect(buffer.String()).NotTo(ContainSubstring("auth.conf.orig"))
+		})
+
+		context("when the setting is also set in the environment", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_COMPRESSION_LEVEL", "9")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_COMPRESSION_LEVEL")).To(Succeed())
+			})
+
+			it("prefers the environment", func() {
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring("DeflateCompressionLevel 9"))
+			})
+		})
+
+		context("when the profile is not declared anywhere", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_PROFILE", "production")).To(Succeed())
+			})
+
+			it("warns about it", func() {
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring("DeflateCompressionLevel 4"))
+				Expect(buffer.String()).To(ContainSubstring("Warning: profile production has no settings in .httpd.toml or project.toml and no .httpd.conf.d/profiles/production directory"))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the profile name is not valid", func() {
+				it.Before(func() {
+					Expect(os.Setenv("BP_PHP_HTTPD_PROFILE", "../staging")).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := config.Write(layerDir, workingDir)
+					Expect(err).To(MatchError(`$BP_PHP_HTTPD_PROFILE must only contain letters, digits, '.', '-' and '_': "../staging"`))
+				})
+			})
+		})
+	})
+}
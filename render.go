@@ -0,0 +1,56 @@
+package phphttpd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Render writes the HTTPD configuration for the application in workingDir
+// into a temporary layer directory and copies it to output, so that it can
+// be inspected without running a build. The environment of the current
+// process is used, just as during a build. A relative workingDir is resolved
+// against the current directory, since the configuration refers to the
+// application by its absolute path. The layer directory is kept, since the
+// configuration refers to the files written into it, and a comment at the
+// top of the output names it.
+func Render(config ConfigWriter, workingDir string, output io.Writer) error {
+	workingDir, err := filepath.Abs(workingDir)
+	if err != nil {
+		// untested
+		return fmt.Errorf("failed to resolve application directory: %w", err)
+	}
+
+	layerPath, err := os.MkdirTemp("", "php-httpd-config")
+	if err != nil {
+		// untested
+		return fmt.Errorf("failed to create temporary layer: %w", err)
+	}
+
+	metadata, err := config.Write(layerPath, workingDir)
+	if err != nil {
+		os.RemoveAll(layerPath)
+		return err
+	}
+
+	file, err := os.Open(metadata.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to open rendered configuration: %w", err)
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(output, "# Rendered into %s, which holds the files this configuration refers to\n", layerPath)
+	if err != nil {
+		// untested
+		return fmt.Errorf("failed to print rendered configuration: %w", err)
+	}
+
+	_, err = io.Copy(output, file)
+	if err != nil {
+		// untested
+		return fmt.Errorf("failed to print rendered configuration: %w", err)
+	}
+
+	return nil
+}
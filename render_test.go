@@ -0,0 +1,101 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/paketo-buildpacks/php-httpd/fakes"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testRender(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		config *fakes.ConfigWriter
+		output *bytes.Buffer
+	)
+
+	it.Before(func() {
+		output = bytes.NewBuffer(nil)
+
+		config = &fakes.ConfigWriter{}
+		config.WriteCall.Stub = func(layerPath, workingDir string) (phphttpd.Metadata, error) {
+			path := filepath.Join(layerPath, "httpd.conf")
+			return phphttpd.Metadata{ConfigPath: path}, os.WriteFile(path, []byte("ServerRoot \"${SERVER_ROOT}\"\n"), 0644)
+		}
+	})
+
+	it("prints the configuration rendered into a temporary layer", func() {
+		err := phphttpd.Render(config, "some-app", output)
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err := filepath.Abs("some-app")
+		Expect(err).NotTo(HaveOccurred())
+
+		layerPath := config.WriteCall.Receives.LayerPath
+		defer os.RemoveAll(layerPath)
+
+		Expect(config.WriteCall.Receives.WorkingDir).To(Equal(workingDir))
+		Expect(filepath.Join(layerPath, "httpd.conf")).To(BeARegularFile())
+		Expect(output.String()).To(Equal("# Rendered into " + layerPath + ", which holds the files this configuration refers to\nServerRoot \"${SERVER_ROOT}\"\n"))
+	})
+
+	context("when the application directory is relative", func() {
+		var (
+			workingDir string
+			cwd        string
+		)
+
+		it.Before(func() {
+			var err error
+			cwd, err = os.Getwd()
+			Expect(err).NotTo(HaveOccurred())
+
+			workingDir, err = os.MkdirTemp("", "workingDir")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+			Expect(os.Chdir(workingDir)).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Chdir(cwd)).To(Succeed())
+			Expect(os.RemoveAll(workingDir)).To(Succeed())
+		})
+
+		it("renders the configuration for the current directory", func() {
+			err := phphttpd.Render(phphttpd.NewConfig(scribe.NewEmitter(bytes.NewBuffer(nil))), ".", output)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(output.String()).To(ContainSubstring(`DocumentRoot "` + filepath.Join(workingDir, "htdocs") + `"`))
+
+			layerPath, _, ok := strings.Cut(strings.TrimPrefix(output.String(), "# Rendered into "), ",")
+			Expect(ok).To(BeTrue())
+			Expect(filepath.Join(layerPath, phphttpd.MetadataFile)).To(BeARegularFile())
+			Expect(os.RemoveAll(layerPath)).To(Succeed())
+		})
+	})
+
+	context("failure cases", func() {
+		context("when the configuration cannot be written", func() {
+			it.Before(func() {
+				config.WriteCall.Stub = nil
+				config.WriteCall.Returns.Error = errors.New("failed to write config")
+			})
+
+			it("returns an error and removes the temporary layer", func() {
+				err := phphttpd.Render(config, "some-app", output)
+				Expect(err).To(MatchError("failed to write config"))
+				Expect(config.WriteCall.Receives.LayerPath).NotTo(BeADirectory())
+			})
+		})
+	})
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/paketo-buildpacks/packit/v2"
 	"github.com/paketo-buildpacks/packit/v2/scribe"
@@ -9,6 +11,24 @@ import (
 )
 
 func main() {
+	// Invoked directly as "run [app-dir]", the buildpack renders the HTTPD
+	// configuration for the given application and prints it, along with the
+	// effective settings on stderr.
+	if filepath.Base(os.Args[0]) == "run" {
+		workingDir := "."
+		if len(os.Args) > 1 {
+			workingDir = os.Args[1]
+		}
+
+		logEmitter := scribe.NewEmitter(os.Stderr).WithLevel(os.Getenv("BP_LOG_LEVEL"))
+		err := phphttpd.Render(phphttpd.NewConfig(logEmitter), workingDir, os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	logEmitter := scribe.NewEmitter(os.Stdout).WithLevel(os.Getenv("BP_LOG_LEVEL"))
 	config := phphttpd.NewConfig(logEmitter)
 
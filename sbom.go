@@ -0,0 +1,301 @@
+package phphttpd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/paketo-buildpacks/packit/v2"
+)
+
+// The SBOM media types a platform can request, as declared in
+// buildpack.toml.
+const (
+	CycloneDXFormat = "application/vnd.cyclonedx+json"
+	SPDXFormat      = "application/spdx+json"
+	SyftFormat      = "application/vnd.syft+json"
+)
+
+// SBOM lists what the configuration layer contains. It is encoded directly
+// rather than through packit's sbom package, whose syft dependency does not
+// build against the CycloneDX version pinned in go.mod. Like packit, the
+// documents leave out anything that changes between identical builds.
+type SBOM struct {
+	Source   string
+	Version  string
+	Packages []SBOMPackage
+}
+
+// SBOMPackage is a single entry of the SBOM. Type is a Syft package type.
+// Location is empty for entries, such as HTTPD modules, that are not files in
+// the layer.
+type SBOMPackage struct {
+	Name     string
+	Version  string
+	Type     string
+	PURL     string
+	Location string
+}
+
+// GenerateSBOM describes the contents of the configuration layer: the
+// configuration rendered from this buildpack's template, the HTTPD modules it
+// loads and the files it references.
+func GenerateSBOM(metadata Metadata, version, layerPath string) SBOM {
+	checksum := sha256.Sum256([]byte(DefaultHTTPDConfTemplate))
+
+	packages := []SBOMPackage{
+		{
+			Name:     PhpHttpdConfig,
+			Version:  version,
+			Type:     "UnknownPackage",
+			PURL:     "pkg:generic/" + PhpHttpdConfig + "@" + version + "?checksum=sha256:" + hex.EncodeToString(checksum[:]),
+			Location: metadata.ConfigPath,
+		},
+	}
+
+	for _, module := range metadata.Modules {
+		packages = append(packages, SBOMPackage{
+			Name: "mod_" + module,
+			Type: "binary",
+			PURL: "pkg:generic/httpd-modules/mod_" + module,
+		})
+	}
+
+	for _, asset := range metadata.Assets {
+		name, err := filepath.Rel(layerPath, asset)
+		if err != nil {
+			// untested
+			name = filepath.Base(asset)
+		}
+
+		packages = append(packages, SBOMPackage{
+			Name:     name,
+			Type:     "UnknownPackage",
+			Location: asset,
+		})
+	}
+
+	return SBOM{Source: layerPath, Version: version, Packages: packages}
+}
+
+// InFormats encodes the SBOM in each of the given media types. Like packit,
+// it accepts a version parameter for CycloneDX (1.3) and SPDX (2.2).
+func (s SBOM) InFormats(mediaTypes ...string) (packit.SBOMFormats, error) {
+	var formats packit.SBOMFormats
+	for _, mediaType := range mediaTypes {
+		baseType, params, err := mime.ParseMediaType(mediaType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SBOM media type: %w", err)
+		}
+
+		var (
+			extension string
+			document  interface{}
+			supported = map[string]bool{"": true}
+		)
+		switch baseType {
+		case CycloneDXFormat:
+			extension, document, supported["1.3"] = "cdx.json", s.cycloneDX(), true
+		case SPDXFormat:
+			extension, supported["2.2"] = "spdx.json", true
+			document, err = s.spdx()
+			if err != nil {
+				return nil, err
+			}
+		case SyftFormat:
+			extension, document = "syft.json", s.syft()
+		default:
+			return nil, fmt.Errorf("unsupported SBOM format: '%s'", mediaType)
+		}
+
+		if !supported[params["version"]] {
+			return nil, fmt.Errorf("version '%s' is not supported for SBOM format '%s'", params["version"], baseType)
+		}
+
+		content, err := json.MarshalIndent(document, "", "  ")
+		if err != nil {
+			// untested
+			return nil, fmt.Errorf("failed to encode SBOM: %w", err)
+		}
+
+		formats = append(formats, packit.SBOMFormat{
+			Extension: extension,
+			Content:   bytes.NewReader(content),
+		})
+	}
+
+	return formats, nil
+}
+
+func (s SBOM) cycloneDX() interface{} {
+	type component struct {
+		Type    string `json:"type"`
+		Name    string `json:"name"`
+		Version string `json:"version,omitempty"`
+		PURL    string `json:"purl,omitempty"`
+	}
+
+	components := []component{}
+	for _, p := range s.Packages {
+		kind := "library"
+		if p.Location != "" {
+			kind = "file"
+		}
+		components = append(components, component{Type: kind, Name: p.Name, Version: p.Version, PURL: p.PURL})
+	}
+
+	return map[string]interface{}{
+		"bomFormat":   "CycloneDX",
+		"specVersion": "1.3",
+		"version":     1,
+		"metadata": map[string]interface{}{
+			"component": component{Type: "file", Name: s.Source},
+		},
+		"components": components,
+	}
+}
+
+func (s SBOM) spdx() (interface{}, error) {
+	type externalRef struct {
+		Category string `json:"referenceCategory"`
+		Type     string `json:"referenceType"`
+		Locator  string `json:"referenceLocator"`
+	}
+
+	type spdxPackage struct {
+		ID               string        `json:"SPDXID"`
+		Name             string        `json:"name"`
+		Version          string        `json:"versionInfo,omitempty"`
+		DownloadLocation string        `json:"downloadLocation"`
+		FilesAnalyzed    bool          `json:"filesAnalyzed"`
+		LicenseConcluded string        `json:"licenseConcluded"`
+		LicenseDeclared  string        `json:"licenseDeclared"`
+		CopyrightText    string        `json:"copyrightText"`
+		ExternalRefs     []externalRef `json:"externalRefs,omitempty"`
+	}
+
+	type relationship struct {
+		Element      string `json:"spdxElementId"`
+		Type         string `json:"relationshipType"`
+		RelatedToElt string `json:"relatedSpdxElement"`
+	}
+
+	packages := []spdxPackage{}
+	relationships := []relationship{}
+	for i, p := range s.Packages {
+		entry := spdxPackage{
+			ID:               fmt.Sprintf("SPDXRef-Package-%d", i),
+			Name:             p.Name,
+			Version:          p.Version,
+			DownloadLocation: "NOASSERTION",
+			LicenseConcluded: "NOASSERTION",
+			LicenseDeclared:  "NOASSERTION",
+			CopyrightText:    "NOASSERTION",
+		}
+		if p.PURL != "" {
+			entry.ExternalRefs = []externalRef{{Category: "PACKAGE_MANAGER", Type: "purl", Locator: p.PURL}}
+		}
+		packages = append(packages, entry)
+		relationships = append(relationships, relationship{Element: "SPDXRef-DOCUMENT", Type: "DESCRIBES", RelatedToElt: entry.ID})
+	}
+
+	// As in packit, the creation time is $SOURCE_DATE_EPOCH or the zero time,
+	// and the namespace is derived from the rest of the document.
+	created := time.Time{}
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		seconds, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SOURCE_DATE_EPOCH: %w", err)
+		}
+		created = time.Unix(seconds, 0).UTC()
+	}
+
+	document := map[string]interface{}{
+		"spdxVersion": "SPDX-2.2",
+		"dataLicense": "CC0-1.0",
+		"SPDXID":      "SPDXRef-DOCUMENT",
+		"name":        s.Source,
+		"creationInfo": map[string]interface{}{
+			"created":  created.Format(time.RFC3339),
+			"creators": []string{"Tool: " + PhpHttpdConfig},
+		},
+		"packages":      packages,
+		"relationships": relationships,
+	}
+
+	content, err := json.Marshal(document)
+	if err != nil {
+		// untested
+		return nil, fmt.Errorf("failed to checksum SPDX document: %w", err)
+	}
+	checksum := sha256.Sum256(content)
+	document["documentNamespace"] = "https://paketo.io/php-httpd/dir/" + hex.EncodeToString(checksum[:])
+
+	return document, nil
+}
+
+func (s SBOM) syft() interface{} {
+	type location struct {
+		Path string `json:"path"`
+	}
+
+	type artifact struct {
+		ID        string     `json:"id"`
+		Name      string     `json:"name"`
+		Version   string     `json:"version"`
+		Type      string     `json:"type"`
+		FoundBy   string     `json:"foundBy"`
+		Locations []location `json:"locations"`
+		Licenses  []string   `json:"licenses"`
+		Language  string     `json:"language"`
+		CPEs      []string   `json:"cpes"`
+		PURL      string     `json:"purl"`
+	}
+
+	sourceID := sha256.Sum256([]byte(s.Source))
+
+	artifacts := []artifact{}
+	for i, p := range s.Packages {
+		entry := artifact{
+			ID:        fmt.Sprintf("%s-%d", PhpHttpdConfig, i),
+			Name:      p.Name,
+			Version:   p.Version,
+			Type:      p.Type,
+			FoundBy:   PhpHttpdConfig,
+			Locations: []location{},
+			Licenses:  []string{},
+			CPEs:      []string{},
+			PURL:      p.PURL,
+		}
+		if p.Location != "" {
+			entry.Locations = append(entry.Locations, location{Path: p.Location})
+		}
+		artifacts = append(artifacts, entry)
+	}
+
+	// The distro is left empty, as Syft does for a directory source.
+	return map[string]interface{}{
+		"artifacts":             artifacts,
+		"artifactRelationships": []interface{}{},
+		"source": map[string]interface{}{
+			"id":       hex.EncodeToString(sourceID[:]),
+			"name":     s.Source,
+			"version":  "",
+			"type":     "directory",
+			"metadata": map[string]interface{}{"path": s.Source},
+		},
+		"distro":     map[string]interface{}{},
+		"descriptor": map[string]interface{}{"name": PhpHttpdConfig, "version": s.Version},
+		"schema": map[string]interface{}{
+			"version": "16.1.10",
+			"url":     "https://raw.githubusercontent.com/anchore/syft/main/schema/json/schema-16.1.10.json",
+		},
+	}
+}
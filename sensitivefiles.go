@@ -0,0 +1,181 @@
+package phphttpd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// defaultSensitiveFiles are denied unless
+// $BP_PHP_HTTPD_ENABLE_SENSITIVE_FILE_DEFAULTS is false. Entries ending in a
+// slash are directories, which are only denied at the application root so
+// that, for example, assets published to public/vendor are still served.
+var defaultSensitiveFiles = []string{
+	"composer.json",
+	"composer.lock",
+	"auth.json",
+	"*.sql",
+	"*.bak",
+	"*.log",
+	"vendor/",
+}
+
+// SensitiveFiles are file name patterns that are never served. A * matches
+// any sequence of characters and a ? matches a single one. Directories are
+// denied at any depth, RootDirectories only at the application root.
+type SensitiveFiles struct {
+	Files           []string
+	Directories     []string
+	RootDirectories []string
+}
+
+// FilesPattern returns the regular expression of a FilesMatch section that
+// matches any of the denied file names.
+func (f SensitiveFiles) FilesPattern() string {
+	if len(f.Files) == 0 {
+		return ""
+	}
+	return "^" + globsPattern(f.Files) + "$"
+}
+
+// DirectoriesPattern returns the regular expression of a DirectoryMatch
+// section that matches any of the denied directories below root.
+func (f SensitiveFiles) DirectoriesPattern(root string) string {
+	var patterns []string
+	if len(f.RootDirectories) > 0 {
+		patterns = append(patterns, globsPattern(f.RootDirectories))
+	}
+	if len(f.Directories) > 0 {
+		patterns = append(patterns, "(.+/)?"+globsPattern(f.Directories))
+	}
+
+	switch len(patterns) {
+	case 0:
+		return ""
+	case 1:
+		return "^" + regexp.QuoteMeta(root) + "/" + patterns[0] + "(/|$)"
+	default:
+		return "^" + regexp.QuoteMeta(root) + "/(" + strings.Join(patterns, "|") + ")(/|$)"
+	}
+}
+
+// DeniedDirectories returns the names of all denied directories.
+func (f SensitiveFiles) DeniedDirectories() []string {
+	var directories []string
+	directories = append(directories, f.RootDirectories...)
+	for _, directory := range f.Directories {
+		directories = appendUnique(directories, directory)
+	}
+	return directories
+}
+
+func globsPattern(globs []string) string {
+	var patterns []string
+	for _, glob := range globs {
+		pattern := regexp.QuoteMeta(glob)
+		pattern = strings.ReplaceAll(pattern, `\*`, `[^/]*`)
+		pattern = strings.ReplaceAll(pattern, `\?`, `[^/]`)
+		patterns = append(patterns, pattern)
+	}
+
+	return "(" + strings.Join(patterns, "|") + ")"
+}
+
+func loadSensitiveFiles(s settings) (SensitiveFiles, error) {
+	enableDefaults, err := s.lookupBool("BP_PHP_HTTPD_ENABLE_SENSITIVE_FILE_DEFAULTS", true)
+	if err != nil {
+		return SensitiveFiles{}, err
+	}
+
+	var files SensitiveFiles
+	if enableDefaults {
+		for _, entry := range defaultSensitiveFiles {
+			if strings.HasSuffix(entry, "/") {
+				files.RootDirectories = append(files.RootDirectories, strings.TrimSuffix(entry, "/"))
+			} else {
+				files.Files = append(files.Files, entry)
+			}
+		}
+	}
+
+	for _, entry := range s.lookupList("BP_PHP_HTTPD_DENY_FILES") {
+		name := strings.TrimSuffix(entry, "/")
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\"\\[]") || strings.IndexFunc(name, isControl) >= 0 {
+			return SensitiveFiles{}, fmt.Errorf("%s entries must be file names, optionally with * and ? wildcards, ending in / for directories: %q", s.describe("BP_PHP_HTTPD_DENY_FILES"), entry)
+		}
+
+		if strings.HasSuffix(entry, "/") {
+			files.Directories = appendUnique(files.Directories, name)
+		} else {
+			files.Files = appendUnique(files.Files, entry)
+		}
+	}
+
+	return files, nil
+}
+
+// warnExposedFiles warns when the application root is served, listing the
+// top-level files and directories that are usually not meant to be public,
+// and whether they are denied.
+func (c Config) warnExposedFiles(workingDir, webDir string, files SensitiveFiles) error {
+	if webDir != "." {
+		return nil
+	}
+
+	entries, err := os.ReadDir(workingDir)
+	if err != nil {
+		// untested
+		return fmt.Errorf("failed to read application directory: %w", err)
+	}
+
+	var denied, exposed []string
+	for _, entry := range entries {
+		name := entry.Name()
+		globs, defaults := files.Files, defaultSensitiveFiles
+		if entry.IsDir() {
+			name += "/"
+			globs = files.DeniedDirectories()
+		}
+
+		switch {
+		case matchesAny(globs, entry.Name()):
+			denied = append(denied, name)
+		case matchesAny(defaults, name):
+			exposed = append(exposed, name)
+		}
+	}
+
+	if len(denied) == 0 && len(exposed) == 0 {
+		return nil
+	}
+
+	c.logger.Subprocess("Warning: the application root is served as the web directory, consider moving public files to a subdirectory such as public")
+	if len(denied) > 0 {
+		c.logger.Action("Access denied to: %s", strings.Join(denied, ", "))
+	}
+	if len(exposed) > 0 {
+		c.logger.Action("Publicly accessible: %s", strings.Join(exposed, ", "))
+	}
+
+	return nil
+}
+
+func matchesAny(globs []string, name string) bool {
+	for _, glob := range globs {
+		if ok, _ := filepath.Match(glob, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,184 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testSensitiveFiles(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		buffer     *bytes.Buffer
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+
+		buffer = bytes.NewBuffer(nil)
+		config = phphttpd.NewConfig(scribe.NewEmitter(buffer))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("denies the built-in patterns by default", func() {
+		metadata, err := config.Write(layerDir, workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(metadata.ConfigPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(ContainSubstring(`<FilesMatch "^(composer\.json|composer\.lock|auth\.json|[^/]*\.sql|[^/]*\.bak|[^/]*\.log)$">
+    Require all denied
+</FilesMatch>`))
+		Expect(string(contents)).To(ContainSubstring(`<DirectoryMatch "^` + workingDir + `/(vendor)(/|$)">
+    Require all denied
+</DirectoryMatch>`))
+	})
+
+	it("only denies the vendor directory at the application root", func() {
+		metadata, err := config.Write(layerDir, workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(metadata.ConfigPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		match := regexp.MustCompile(`<DirectoryMatch "(\^` + regexp.QuoteMeta(workingDir) + `.*)">`).FindStringSubmatch(string(contents))
+		Expect(match).To(HaveLen(2))
+
+		directories := regexp.MustCompile(match[1])
+		Expect(directories.MatchString(filepath.Join(workingDir, "vendor", "laravel"))).To(BeTrue())
+		Expect(directories.MatchString(filepath.Join(workingDir, "public", "vendor", "horizon"))).To(BeFalse())
+		Expect(directories.MatchString(filepath.Join(workingDir, "public", "js", "vendor"))).To(BeFalse())
+	})
+
+	context("when $BP_PHP_HTTPD_DENY_FILES is set", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_DENY_FILES", "*.dist, node_modules/")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_DENY_FILES")).To(Succeed())
+		})
+
+		it("extends the built-in patterns", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`|[^/]*\.log|[^/]*\.dist)$">`))
+			Expect(string(contents)).To(ContainSubstring(`/((vendor)|(.+/)?(node_modules))(/|$)">`))
+		})
+	})
+
+	context("when $BP_PHP_HTTPD_ENABLE_SENSITIVE_FILE_DEFAULTS is false", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_ENABLE_SENSITIVE_FILE_DEFAULTS", "false")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_ENABLE_SENSITIVE_FILE_DEFAULTS")).To(Succeed())
+		})
+
+		it("does not deny any files", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).NotTo(ContainSubstring("Never serve dependency manifests"))
+		})
+	})
+
+	context("when the application root is the web directory", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_WEB_DIR", ".")).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "composer.json"), nil, 0600)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "index.php"), nil, 0600)).To(Succeed())
+			Expect(os.Mkdir(filepath.Join(workingDir, "vendor"), os.ModePerm)).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_WEB_DIR")).To(Succeed())
+		})
+
+		it("warns about the files that are denied", func() {
+			_, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).To(ContainSubstring("Warning: the application root is served as the web directory"))
+			Expect(buffer.String()).To(ContainSubstring("Access denied to: composer.json, vendor/"))
+			Expect(buffer.String()).NotTo(ContainSubstring("Publicly accessible"))
+		})
+
+		context("when the built-in patterns are disabled", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_ENABLE_SENSITIVE_FILE_DEFAULTS", "false")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_ENABLE_SENSITIVE_FILE_DEFAULTS")).To(Succeed())
+			})
+
+			it("warns about the files that are exposed", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("Publicly accessible: composer.json, vendor/"))
+			})
+		})
+	})
+
+	context("failure cases", func() {
+		context("when an entry is a path", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_DENY_FILES", "config/secrets.yml")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_DENY_FILES")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(`$BP_PHP_HTTPD_DENY_FILES entries must be file names, optionally with * and ? wildcards, ending in / for directories: "config/secrets.yml"`))
+			})
+		})
+
+		context("when an entry uses a character class", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_DENY_FILES", "[abc")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_DENY_FILES")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`ending in / for directories: "[abc"`)))
+			})
+		})
+	})
+}
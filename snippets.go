@@ -0,0 +1,159 @@
+package phphttpd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// SnippetsDirectory is the directory of the layer that the user-provided
+// configuration is written to when it contains templates.
+const SnippetsDirectory = "httpd.conf.d"
+
+// renderSnippets renders the .conf.tmpl files of .httpd.conf.d and its hook
+// directories with the same data as the main configuration. A directory with
+// templates is copied to the layer, with each template replaced by its
+// output, and included from there instead.
+func (c Config) renderSnippets(s settings, layerPath, workingDir string, data *HttpdConfig) error {
+	enabled, err := s.lookupBool("BP_PHP_HTTPD_RENDER_TEMPLATES", false)
+	if err != nil {
+		return err
+	}
+
+	includes := []struct {
+		hook string
+		glob *string
+	}{
+		{"", &data.UserInclude},
+		{filepath.Join("profiles", data.Profile), &data.ProfileInclude},
+		{"pre", &data.IncludeHooks.Pre},
+		{"directory", &data.IncludeHooks.Directory},
+		{"post", &data.IncludeHooks.Post},
+	}
+
+	// The includes are replaced only once every template rendered, so that
+	// each one sees the same data.
+	globs := make([]string, len(includes))
+	for i, include := range includes {
+		globs[i] = *include.glob
+		if *include.glob == "" {
+			continue
+		}
+
+		source := filepath.Join(workingDir, ".httpd.conf.d", include.hook)
+		templates, err := filepath.Glob(filepath.Join(source, "*.conf.tmpl"))
+		if err != nil {
+			// untested
+			return err
+		}
+		if len(templates) == 0 {
+			continue
+		}
+
+		if !enabled {
+			c.logger.Subprocess("Warning: ignoring templates in %s, set $BP_PHP_HTTPD_RENDER_TEMPLATES to true to render them", relativeSnippetPath(workingDir, source))
+			continue
+		}
+
+		destination := filepath.Join(layerPath, SnippetsDirectory, include.hook)
+		rendered, err := renderSnippetDirectory(source, destination, templates, *data)
+		if err != nil {
+			return err
+		}
+
+		c.logger.Subprocess("Rendered configuration templates:")
+		for _, file := range rendered {
+			c.logger.Action("%s", relativeSnippetPath(workingDir, filepath.Join(source, file+".tmpl")))
+			data.Snippets = append(data.Snippets, filepath.Join(destination, file))
+		}
+
+		globs[i] = filepath.Join(destination, "*.conf")
+	}
+
+	for i, include := range includes {
+		*include.glob = globs[i]
+	}
+
+	return nil
+}
+
+// renderSnippetDirectory copies the .conf files of source to destination and
+// writes the output of each template next to them, returning the names of
+// the rendered files.
+func renderSnippetDirectory(source, destination string, templates []string, data HttpdConfig) ([]string, error) {
+	err := os.RemoveAll(destination)
+	if err != nil {
+		// untested
+		return nil, err
+	}
+
+	err = os.MkdirAll(destination, os.ModePerm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", destination, err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(source, "*.conf"))
+	if err != nil {
+		// untested
+		return nil, err
+	}
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+
+		err = os.WriteFile(filepath.Join(destination, filepath.Base(file)), content, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", filepath.Base(file), err)
+		}
+	}
+
+	var rendered []string
+	sort.Strings(templates)
+	for _, path := range templates {
+		name := strings.TrimSuffix(filepath.Base(path), ".tmpl")
+		if _, err := os.Stat(filepath.Join(source, name)); err == nil {
+			return nil, fmt.Errorf("both %s and %s exist in %s", name, filepath.Base(path), source)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs()).Option("missingkey=error").Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		var b bytes.Buffer
+		err = tmpl.Execute(&b, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s: %w", path, err)
+		}
+
+		err = os.WriteFile(filepath.Join(destination, name), b.Bytes(), 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+
+		rendered = append(rendered, name)
+	}
+
+	return rendered, nil
+}
+
+func relativeSnippetPath(workingDir, path string) string {
+	rel, err := filepath.Rel(workingDir, path)
+	if err != nil {
+		// untested
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
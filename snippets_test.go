@@ -0,0 +1,136 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testSnippets(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		buffer     *bytes.Buffer
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+
+		Expect(os.MkdirAll(filepath.Join(workingDir, ".httpd.conf.d", "post"), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.conf.d", "app.conf"), []byte("ServerSignature On\n"), 0600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.conf.d", "uploads.conf.tmpl"), []byte(`<Directory "{{.DocumentRoot}}/uploads">
+    Require all denied
+</Directory>
+# {{env "SNIPPET_VALUE"}} {{.FpmSocket}}
+`), 0600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.conf.d", "post", "robots.conf.tmpl"), []byte("Header set X-Robots-Tag noindex\n"), 0600)).To(Succeed())
+
+		buffer = bytes.NewBuffer(nil)
+		config = phphttpd.NewConfig(scribe.NewEmitter(buffer))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("ignores templates and warns about them by default", func() {
+		metadata, err := config.Write(layerDir, workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(metadata.ConfigPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(ContainSubstring("IncludeOptional \"" + filepath.Join(workingDir, ".httpd.conf.d", "*.conf") + "\""))
+		Expect(filepath.Join(layerDir, "httpd.conf.d")).NotTo(BeADirectory())
+		Expect(buffer.String()).To(ContainSubstring("Warning: ignoring templates in .httpd.conf.d, set $BP_PHP_HTTPD_RENDER_TEMPLATES to true to render them"))
+	})
+
+	context("when $BP_PHP_HTTPD_RENDER_TEMPLATES is true", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_RENDER_TEMPLATES", "true")).To(Succeed())
+			Expect(os.Setenv("SNIPPET_VALUE", "some-value")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_RENDER_TEMPLATES")).To(Succeed())
+			Expect(os.Unsetenv("SNIPPET_VALUE")).To(Succeed())
+		})
+
+		it("renders the templates into the layer and includes them from there", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("IncludeOptional \"" + filepath.Join(layerDir, "httpd.conf.d", "*.conf") + "\""))
+			Expect(string(contents)).To(ContainSubstring("IncludeOptional \"" + filepath.Join(layerDir, "httpd.conf.d", "post", "*.conf") + "\""))
+
+			rendered, err := os.ReadFile(filepath.Join(layerDir, "httpd.conf.d", "uploads.conf"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(rendered)).To(Equal(`<Directory "` + filepath.Join(workingDir, "htdocs") + `/uploads">
+    Require all denied
+</Directory>
+# some-value 127.0.0.1:9000
+`))
+
+			copied, err := os.ReadFile(filepath.Join(layerDir, "httpd.conf.d", "app.conf"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(copied)).To(Equal("ServerSignature On\n"))
+			Expect(filepath.Join(layerDir, "httpd.conf.d", "post", "robots.conf")).To(BeARegularFile())
+
+			Expect(buffer.String()).To(ContainSubstring("Rendered configuration templates:"))
+			Expect(buffer.String()).To(ContainSubstring(".httpd.conf.d/post/robots.conf.tmpl"))
+		})
+
+		context("failure cases", func() {
+			context("when a template cannot be parsed", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.conf.d", "broken.conf.tmpl"), []byte("{{.WebDirectory"), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := config.Write(layerDir, workingDir)
+					Expect(err).To(MatchError(ContainSubstring("failed to parse " + filepath.Join(workingDir, ".httpd.conf.d", "broken.conf.tmpl"))))
+				})
+			})
+
+			context("when a template refers to an unknown field", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.conf.d", "broken.conf.tmpl"), []byte("{{.WebRoot}}"), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := config.Write(layerDir, workingDir)
+					Expect(err).To(MatchError(ContainSubstring("failed to render " + filepath.Join(workingDir, ".httpd.conf.d", "broken.conf.tmpl"))))
+				})
+			})
+
+			context("when a template would overwrite a configuration file", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.conf.d", "app.conf.tmpl"), nil, 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := config.Write(layerDir, workingDir)
+					Expect(err).To(MatchError(ContainSubstring("both app.conf and app.conf.tmpl exist in")))
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,81 @@
+package phphttpd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// defaultPort is the port the server is started on when the platform does
+// not set $PORT.
+const defaultPort = 8080
+
+// Status describes the mod_status and php-fpm status endpoints used for
+// metrics scraping. Access is limited to the Allow CIDRs, and the endpoints
+// are only served on Port when it is set.
+type Status struct {
+	Enabled       bool
+	Path          string
+	FpmStatusPath string
+	Allow         []string
+	Port          int
+}
+
+func loadStatus(s settings) (Status, error) {
+	enabled, err := s.lookupBool("BP_PHP_HTTPD_ENABLE_STATUS", false)
+	if err != nil {
+		return Status{}, err
+	}
+
+	if !enabled {
+		return Status{}, nil
+	}
+
+	status := Status{
+		Enabled:       true,
+		Path:          s.get("BP_PHP_HTTPD_STATUS_PATH"),
+		FpmStatusPath: s.get("BP_PHP_HTTPD_FPM_STATUS_PATH"),
+		Allow:         s.lookupList("BP_PHP_HTTPD_STATUS_ALLOW"),
+	}
+
+	if status.Path == "" {
+		status.Path = "/server-status"
+	}
+
+	err = validateURLPath(s, "BP_PHP_HTTPD_STATUS_PATH", status.Path)
+	if err != nil {
+		return Status{}, err
+	}
+
+	if status.FpmStatusPath != "" {
+		err = validateURLPath(s, "BP_PHP_HTTPD_FPM_STATUS_PATH", status.FpmStatusPath)
+		if err != nil {
+			return Status{}, err
+		}
+	}
+
+	for _, allow := range status.Allow {
+		if !isIPOrCIDR(allow) {
+			return Status{}, fmt.Errorf("%s contains an invalid IP address or CIDR: %q", s.describe("BP_PHP_HTTPD_STATUS_ALLOW"), allow)
+		}
+	}
+
+	if port, ok := s.lookup("BP_PHP_HTTPD_STATUS_PORT"); ok {
+		status.Port, err = strconv.Atoi(port)
+		if err != nil || status.Port < 1 || status.Port > 65535 {
+			return Status{}, fmt.Errorf("%s must be a port number between 1 and 65535: %q", s.describe("BP_PHP_HTTPD_STATUS_PORT"), port)
+		}
+
+		// The server already listens on $PORT, and a second Listen for the
+		// same port stops httpd from starting.
+		serverPort := defaultPort
+		if value, ok := os.LookupEnv("PORT"); ok {
+			serverPort, _ = strconv.Atoi(value)
+		}
+		if status.Port == serverPort {
+			return Status{}, fmt.Errorf("%s must differ from the port the server listens on ($PORT, %d by default): %q", s.describe("BP_PHP_HTTPD_STATUS_PORT"), defaultPort, port)
+		}
+	}
+
+	return status, nil
+}
@@ -0,0 +1,264 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testStatus(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+
+		config = phphttpd.NewConfig(scribe.NewEmitter(bytes.NewBuffer(nil)))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("does not load mod_status by default", func() {
+		metadata, err := config.Write(layerDir, workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(metadata.ConfigPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).NotTo(ContainSubstring("status_module"))
+		Expect(string(contents)).NotTo(ContainSubstring("ExtendedStatus"))
+	})
+
+	context("when $BP_PHP_HTTPD_ENABLE_STATUS is true", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_ENABLE_STATUS", "true")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_ENABLE_STATUS")).To(Succeed())
+		})
+
+		it("serves server-status to local clients only", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("LoadModule status_module modules/mod_status.so"))
+			Expect(string(contents)).To(ContainSubstring("ExtendedStatus On"))
+			Expect(string(contents)).To(ContainSubstring(`<Location "/server-status">
+    SetHandler server-status
+    Require local
+</Location>`))
+			Expect(string(contents)).To(ContainSubstring("RewriteCond %{REQUEST_URI} !=/server-status"))
+			Expect(string(contents)).NotTo(ContainSubstring("VirtualHost"))
+		})
+
+		context("when the paths and allowed CIDRs are configured", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_STATUS_PATH", "/httpd-status")).To(Succeed())
+				Expect(os.Setenv("BP_PHP_HTTPD_FPM_STATUS_PATH", "/fpm-status")).To(Succeed())
+				Expect(os.Setenv("BP_PHP_HTTPD_STATUS_ALLOW", "10.0.0.0/8, 192.168.1.1")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_STATUS_PATH")).To(Succeed())
+				Expect(os.Unsetenv("BP_PHP_HTTPD_FPM_STATUS_PATH")).To(Succeed())
+				Expect(os.Unsetenv("BP_PHP_HTTPD_STATUS_ALLOW")).To(Succeed())
+			})
+
+			it("proxies the FPM status path and restricts both endpoints", func() {
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring(`<Location "/httpd-status">
+    SetHandler server-status
+    Require ip 10.0.0.0/8 192.168.1.1
+</Location>`))
+				Expect(string(contents)).To(ContainSubstring(`<Location "/fpm-status">
+    SetHandler proxy:fcgi://127.0.0.1:9000
+    Require ip 10.0.0.0/8 192.168.1.1
+</Location>`))
+				Expect(string(contents)).To(ContainSubstring("RewriteCond %{REQUEST_URI} !=/httpd-status"))
+				Expect(string(contents)).To(ContainSubstring("RewriteCond %{REQUEST_URI} !=/fpm-status"))
+			})
+		})
+
+		context("when $BP_PHP_HTTPD_STATUS_PORT is set", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_STATUS_PORT", "9117")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_STATUS_PORT")).To(Succeed())
+			})
+
+			it("only serves the endpoints on the internal port", func() {
+				metadata, err := config.Write(layerDir, workingDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := os.ReadFile(metadata.ConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(ContainSubstring(`Listen 9117
+
+<VirtualHost *:9117>
+<Location "/server-status">
+    SetHandler server-status
+    Require all granted
+</Location>
+</VirtualHost>`))
+				Expect(string(contents)).NotTo(ContainSubstring("RewriteCond %{REQUEST_URI} !=/server-status"))
+			})
+		})
+	})
+
+	context("failure cases", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_ENABLE_STATUS", "true")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_ENABLE_STATUS")).To(Succeed())
+		})
+
+		context("when $BP_PHP_HTTPD_ENABLE_STATUS cannot be parsed into a bool", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_ENABLE_STATUS", "blah")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse $BP_PHP_HTTPD_ENABLE_STATUS into boolean")))
+			})
+		})
+
+		context("when the status path is not absolute", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_STATUS_PATH", "server-status")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_STATUS_PATH")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("$BP_PHP_HTTPD_STATUS_PATH must start with '/'")))
+			})
+		})
+
+		context("when the status path contains a quote", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_STATUS_PATH", `/st"x`)).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_STATUS_PATH")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("$BP_PHP_HTTPD_STATUS_PATH must start with '/' and must not contain quotes or whitespace")))
+			})
+		})
+
+		context("when the FPM status path is not absolute", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_FPM_STATUS_PATH", "status")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_FPM_STATUS_PATH")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("$BP_PHP_HTTPD_FPM_STATUS_PATH must start with '/'")))
+			})
+		})
+
+		context("when an allowed address is invalid", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_STATUS_ALLOW", "10.0.0.0/8,not-an-ip")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_STATUS_ALLOW")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`$BP_PHP_HTTPD_STATUS_ALLOW contains an invalid IP address or CIDR: "not-an-ip"`)))
+			})
+		})
+
+		context("when the status port is invalid", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_STATUS_PORT", "70000")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_STATUS_PORT")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`$BP_PHP_HTTPD_STATUS_PORT must be a port number between 1 and 65535: "70000"`)))
+			})
+		})
+
+		context("when the status port is the default server port", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_STATUS_PORT", "8080")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_STATUS_PORT")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`$BP_PHP_HTTPD_STATUS_PORT must differ from the port the server listens on ($PORT, 8080 by default): "8080"`)))
+			})
+		})
+
+		context("when the status port is $PORT", func() {
+			it.Before(func() {
+				Expect(os.Setenv("PORT", "9117")).To(Succeed())
+				Expect(os.Setenv("BP_PHP_HTTPD_STATUS_PORT", "9117")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("PORT")).To(Succeed())
+				Expect(os.Unsetenv("BP_PHP_HTTPD_STATUS_PORT")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`$BP_PHP_HTTPD_STATUS_PORT must differ from the port the server listens on ($PORT, 8080 by default): "9117"`)))
+			})
+		})
+	})
+}
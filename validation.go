@@ -0,0 +1,126 @@
+package phphttpd
+
+import (
+	"fmt"
+	"net/mail"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// validateServerAdmin checks that the server admin is a plain email address,
+// so that it cannot break out of its quoted ServerAdmin directive.
+func validateServerAdmin(s settings, admin string) error {
+	address, err := mail.ParseAddress(admin)
+	if err != nil || address.Name != "" || address.Address != admin || strings.ContainsAny(admin, "\"\\") {
+		return fmt.Errorf("%s must be an email address: %q", s.describe("BP_PHP_SERVER_ADMIN"), admin)
+	}
+
+	return nil
+}
+
+// validateWebDirectory checks that the web directory is a relative path that
+// stays inside the application root and returns it in its clean form.
+func validateWebDirectory(s settings, webDir string) (string, error) {
+	if !isAppPath(webDir) {
+		return "", fmt.Errorf("%s must be a relative path inside the application without quotes or control characters: %q", s.describe("BP_PHP_WEB_DIR"), webDir)
+	}
+
+	return filepath.Clean(webDir), nil
+}
+
+// validateAppRoot checks that the application root can be used as a quoted
+// path in the configuration.
+func validateAppRoot(workingDir string) error {
+	if !filepath.IsAbs(workingDir) || strings.ContainsAny(workingDir, "\"\\") || strings.IndexFunc(workingDir, isControl) >= 0 {
+		return fmt.Errorf("application root must be an absolute path without quotes or control characters: %q", workingDir)
+	}
+
+	return nil
+}
+
+// validateURLPath checks that a request path starts with a slash and can be
+// used as a single argument in the configuration.
+func validateURLPath(s settings, env, path string) error {
+	if !isURLPath(path) {
+		return fmt.Errorf("%s must start with '/' and must not contain quotes or whitespace: %q", s.describe(env), path)
+	}
+
+	return nil
+}
+
+// isURLPath reports whether the path starts with a slash and contains no
+// quotes, backslashes, whitespace or control characters.
+func isURLPath(path string) bool {
+	return strings.HasPrefix(path, "/") && !strings.ContainsAny(path, "\"\\ ") && strings.IndexFunc(path, isControl) < 0
+}
+
+// isAppPath reports whether the path is relative, does not escape the
+// directory it is relative to, and can be quoted in the configuration.
+func isAppPath(path string) bool {
+	if path == "" || filepath.IsAbs(path) || strings.ContainsAny(path, "\"\\") || strings.IndexFunc(path, isControl) >= 0 {
+		return false
+	}
+
+	clean := filepath.Clean(path)
+	return clean != ".." && !strings.HasPrefix(clean, "../")
+}
+
+// validate checks every string that is interpolated into the template for
+// control characters other than tabs. Each value has already been validated
+// while loading; this guards against a newline injecting directives through a
+// value that a future option forgets to check.
+func (h HttpdConfig) validate() error {
+	return validateStrings(reflect.ValueOf(h), "HttpdConfig")
+}
+
+func validateStrings(value reflect.Value, path string) error {
+	switch value.Kind() {
+	case reflect.String:
+		if strings.IndexFunc(value.String(), func(r rune) bool { return r != '\t' && isControl(r) }) >= 0 {
+			return fmt.Errorf("invalid configuration value for %s: %q contains a control character", path, value.String())
+		}
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			err := validateStrings(value.Index(i), fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			if !value.Type().Field(i).IsExported() {
+				continue
+			}
+
+			err := validateStrings(value.Field(i), path+"."+value.Type().Field(i).Name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// quote renders a value as a double-quoted HTTPD argument. HTTPD only
+// unescapes a backslash that is followed by a quote or another backslash, so
+// only those backslashes are escaped, which keeps regular expressions such as
+// \.php readable.
+func quote(value string) string {
+	var quoted strings.Builder
+	quoted.WriteByte('"')
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] == '"':
+			quoted.WriteString(`\"`)
+		case value[i] == '\\' && (i+1 == len(value) || value[i+1] == '\\' || value[i+1] == '"'):
+			quoted.WriteString(`\\`)
+		default:
+			quoted.WriteByte(value[i])
+		}
+	}
+	quoted.WriteByte('"')
+
+	return quoted.String()
+}
@@ -0,0 +1,232 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testValidation(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+
+		config = phphttpd.NewConfig(scribe.NewEmitter(bytes.NewBuffer(nil)))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	context("when $BP_PHP_WEB_DIR is not clean", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_WEB_DIR", "./public/")).To(Succeed())
+			Expect(os.Mkdir(filepath.Join(workingDir, "public"), os.ModePerm)).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_WEB_DIR")).To(Succeed())
+		})
+
+		it("renders the clean path", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`DocumentRoot "` + filepath.Join(workingDir, "public") + `"`))
+		})
+	})
+
+	context("failure cases", func() {
+		context("when $BP_PHP_SERVER_ADMIN is not an email address", func() {
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_SERVER_ADMIN")).To(Succeed())
+			})
+
+			for _, admin := range []string{"admin", "admin@localhost\"\nLoadModule evil", "Admin <admin@localhost>", `"a\"b"@localhost`} {
+				admin := admin
+
+				it("rejects "+admin, func() {
+					Expect(os.Setenv("BP_PHP_SERVER_ADMIN", admin)).To(Succeed())
+
+					_, err := config.Write(layerDir, workingDir)
+					Expect(err).To(MatchError(ContainSubstring("$BP_PHP_SERVER_ADMIN must be an email address")))
+				})
+			}
+		})
+
+		context("when $BP_PHP_WEB_DIR is outside of the application", func() {
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_WEB_DIR")).To(Succeed())
+			})
+
+			for _, webDir := range []string{"..", "public/../../etc", "/etc", "public\"\nLoadModule evil", "pub\\lic"} {
+				webDir := webDir
+
+				it("rejects "+webDir, func() {
+					Expect(os.Setenv("BP_PHP_WEB_DIR", webDir)).To(Succeed())
+
+					_, err := config.Write(layerDir, workingDir)
+					Expect(err).To(MatchError(ContainSubstring("$BP_PHP_WEB_DIR must be a relative path inside the application")))
+				})
+			}
+		})
+
+		context("when the web directory in .httpd.toml is invalid", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.toml"), []byte("web_directory = \"../secrets\"\n"), 0600)).To(Succeed())
+			})
+
+			it("names the key in the error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(`web_directory (.httpd.toml, line 1) must be a relative path inside the application without quotes or control characters: "../secrets"`))
+			})
+		})
+
+		context("when the application root cannot be quoted", func() {
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, filepath.Join(workingDir, "app\"root"))
+				Expect(err).To(MatchError(ContainSubstring("application root must be an absolute path without quotes or control characters")))
+			})
+		})
+	})
+}
+
+func FuzzConfigWrite(f *testing.F) {
+	f.Add("admin@localhost", "htdocs", "/health", "/server-status", "/build")
+	f.Add("admin@example.com", "public/", "", "/st\"x", "/build\" x")
+	f.Add("a@b\"\nLoadModule x", "../x", "/health\" x", "/status", "")
+	f.Add("admin@localhost", "web\ndir", "/he\\alth", "/status\\", "/b uild")
+	f.Add("\"admin\"@localhost", ".", "/health\tx", "", "/build'")
+
+	directive := regexp.MustCompile(`(?m)^\s*(ServerAdmin|DocumentRoot|Alias|<Location|<LocationMatch|<Directory|<DirectoryMatch|<FilesMatch)\s.*$`)
+	arguments := map[string]int{"Alias": 2}
+
+	f.Fuzz(func(t *testing.T, admin, webDir, healthPath, statusPath, cachePrefix string) {
+		for _, value := range []string{admin, webDir, healthPath, statusPath, cachePrefix} {
+			if strings.ContainsRune(value, 0) {
+				t.Skip("environment variables cannot contain NUL")
+			}
+		}
+
+		workingDir := t.TempDir()
+		layerDir := t.TempDir()
+		t.Setenv("BP_PHP_SERVER_ADMIN", admin)
+		t.Setenv("BP_PHP_WEB_DIR", webDir)
+		t.Setenv("BP_PHP_HTTPD_VERIFY_WEB_DIR", "false")
+		t.Setenv("BP_PHP_HTTPD_HEALTHCHECK_PATH", healthPath)
+		t.Setenv("BP_PHP_HTTPD_ENABLE_STATUS", "true")
+		t.Setenv("BP_PHP_HTTPD_STATUS_PATH", statusPath)
+		t.Setenv("BP_PHP_HTTPD_CACHE_POLICIES", cachePrefix+"=1d")
+
+		metadata, err := phphttpd.NewConfig(scribe.NewEmitter(bytes.NewBuffer(nil))).Write(layerDir, workingDir)
+		if err != nil {
+			return
+		}
+
+		contents, err := os.ReadFile(metadata.ConfigPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var locations []string
+		for _, line := range directive.FindAllString(string(contents), -1) {
+			args, ok := httpdArguments(line)
+			if !ok {
+				t.Fatalf("unbalanced quotes: %s", line)
+			}
+
+			expected, ok := arguments[args[0]]
+			if !ok {
+				expected = 1
+			}
+			if len(args)-1 != expected {
+				t.Fatalf("expected %d arguments for %s, got %q", expected, args[0], args[1:])
+			}
+
+			switch args[0] {
+			case "DocumentRoot":
+				if args[1] != workingDir && !strings.HasPrefix(args[1], workingDir+"/") {
+					t.Fatalf("DocumentRoot %s is outside of %s", args[1], workingDir)
+				}
+			case "<Location":
+				locations = append(locations, args[1])
+			}
+		}
+
+		if healthPath != "" && !slices.Contains(locations, healthPath) {
+			t.Fatalf("expected a <Location> section for %q, got %q", healthPath, locations)
+		}
+		if statusPath != "" && !slices.Contains(locations, statusPath) {
+			t.Fatalf("expected a <Location> section for %q, got %q", statusPath, locations)
+		}
+	})
+}
+
+// httpdArguments splits a configuration line into its arguments the way
+// HTTPD does, and reports whether every quoted argument is terminated.
+func httpdArguments(line string) ([]string, bool) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "<") {
+		line = strings.TrimSuffix(line, ">")
+	}
+
+	var args []string
+	for {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" {
+			return args, true
+		}
+
+		quote := line[0]
+		if quote != '"' && quote != '\'' {
+			end := strings.IndexAny(line, " \t")
+			if end < 0 {
+				end = len(line)
+			}
+			args = append(args, line[:end])
+			line = line[end:]
+			continue
+		}
+
+		var arg strings.Builder
+		i := 1
+		for ; i < len(line) && line[i] != quote; i++ {
+			if line[i] == '\\' && i+1 < len(line) && (line[i+1] == quote || line[i+1] == '\\') {
+				i++
+			}
+			arg.WriteByte(line[i])
+		}
+		if i == len(line) {
+			return nil, false
+		}
+
+		args = append(args, arg.String())
+		line = line[i+1:]
+	}
+}
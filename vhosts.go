@@ -0,0 +1,160 @@
+package phphttpd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var hostnamePattern = regexp.MustCompile(`^(\*\.)?[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*$`)
+
+// VirtualHost serves a separate web directory for requests to ServerName or
+// one of its ServerAliases.
+type VirtualHost struct {
+	ServerName      string   `toml:"server_name"`
+	ServerAliases   []string `toml:"server_aliases"`
+	WebDirectory    string   `toml:"web_directory"`
+	FrontController string   `toml:"front_controller"`
+	FpmSocket       string   `toml:"fpm_socket"`
+
+	// Root is the absolute path of the web directory.
+	Root string `toml:"-"`
+	// FallbackResource is the URL requests for missing files are sent to.
+	FallbackResource string `toml:"-"`
+	// AllowOverride is shared with the main web directory.
+	AllowOverride string `toml:"-"`
+}
+
+// Mount serves a separate web directory under a request path prefix.
+type Mount struct {
+	Path            string `toml:"path"`
+	WebDirectory    string `toml:"web_directory"`
+	FrontController string `toml:"front_controller"`
+	FpmSocket       string `toml:"fpm_socket"`
+
+	// Root is the absolute path of the web directory.
+	Root string `toml:"-"`
+	// FallbackResource is the URL requests for missing files are sent to.
+	FallbackResource string `toml:"-"`
+	// AllowOverride is shared with the main web directory.
+	AllowOverride string `toml:"-"`
+}
+
+func loadVirtualHosts(file configFile, workingDir, fpmSocket, allowOverride string) ([]VirtualHost, error) {
+	var hosts []VirtualHost
+	for i, host := range file.Hosts {
+		for _, name := range append([]string{host.ServerName}, host.ServerAliases...) {
+			if !hostnamePattern.MatchString(name) {
+				return nil, fmt.Errorf("%s: hosts[%d]: invalid server name %q", file.HostsFile, i, name)
+			}
+		}
+
+		root, err := resolveWebDirectory(workingDir, host.WebDirectory)
+		if err != nil {
+			return nil, fmt.Errorf("%s: hosts[%d] (%s): %w", file.HostsFile, i, host.ServerName, err)
+		}
+		host.Root = root
+		host.AllowOverride = allowOverride
+
+		if host.FrontController != "" {
+			controller, err := resolveFrontController(root, host.FrontController)
+			if err != nil {
+				return nil, fmt.Errorf("%s: hosts[%d] (%s): %w", file.HostsFile, i, host.ServerName, err)
+			}
+			host.FallbackResource = "/" + controller
+		}
+
+		if host.FpmSocket == "" {
+			host.FpmSocket = fpmSocket
+		} else if !isFpmSocket(host.FpmSocket) {
+			return nil, fmt.Errorf("%s: hosts[%d] (%s): fpm_socket must be host:port or the absolute path of a unix socket: %q", file.HostsFile, i, host.ServerName, host.FpmSocket)
+		}
+
+		hosts = append(hosts, host)
+	}
+
+	return hosts, nil
+}
+
+func loadMounts(file configFile, workingDir, fpmSocket, allowOverride string) ([]Mount, error) {
+	var mounts []Mount
+	for i, mount := range file.Mounts {
+		if !strings.HasPrefix(mount.Path, "/") || mount.Path == "/" || strings.ContainsAny(mount.Path, " \t\"") {
+			return nil, fmt.Errorf("%s: mounts[%d]: path must be a request path below '/': %q", file.MountsFile, i, mount.Path)
+		}
+		mount.Path = strings.TrimSuffix(mount.Path, "/")
+
+		root, err := resolveWebDirectory(workingDir, mount.WebDirectory)
+		if err != nil {
+			return nil, fmt.Errorf("%s: mounts[%d] (%s): %w", file.MountsFile, i, mount.Path, err)
+		}
+		mount.Root = root
+		mount.AllowOverride = allowOverride
+
+		if mount.FrontController != "" {
+			controller, err := resolveFrontController(root, mount.FrontController)
+			if err != nil {
+				return nil, fmt.Errorf("%s: mounts[%d] (%s): %w", file.MountsFile, i, mount.Path, err)
+			}
+			mount.FallbackResource = mount.Path + "/" + controller
+		}
+
+		if mount.FpmSocket == "" {
+			mount.FpmSocket = fpmSocket
+		} else if !isFpmSocket(mount.FpmSocket) {
+			return nil, fmt.Errorf("%s: mounts[%d] (%s): fpm_socket must be host:port or the absolute path of a unix socket: %q", file.MountsFile, i, mount.Path, mount.FpmSocket)
+		}
+
+		mounts = append(mounts, mount)
+	}
+
+	return mounts, nil
+}
+
+// resolveWebDirectory returns the absolute path of an existing web directory
+// given relative to the application root.
+func resolveWebDirectory(workingDir, webDir string) (string, error) {
+	clean := filepath.Clean(webDir)
+	if !isAppPath(webDir) {
+		return "", fmt.Errorf("web_directory must be a path inside the application: %q", webDir)
+	}
+
+	root := filepath.Join(workingDir, clean)
+	info, err := os.Stat(root)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("web_directory does not exist: %s", clean)
+		}
+		// untested
+		return "", err
+	}
+
+	if !info.IsDir() {
+		return "", fmt.Errorf("web_directory is not a directory: %s", clean)
+	}
+
+	return root, nil
+}
+
+// resolveFrontController returns the path of an existing front controller,
+// relative to the web directory and with forward slashes.
+func resolveFrontController(root, controller string) (string, error) {
+	clean, ok := cleanPagePath(controller)
+	if !ok {
+		return "", fmt.Errorf("invalid front_controller: %q", controller)
+	}
+
+	_, err := os.Stat(filepath.Join(root, clean))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("front_controller does not exist: %s", clean)
+		}
+		// untested
+		return "", err
+	}
+
+	return clean, nil
+}
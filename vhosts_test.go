@@ -0,0 +1,281 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testVirtualHosts(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(workingDir, "htdocs"), os.ModePerm)).To(Succeed())
+
+		Expect(os.MkdirAll(filepath.Join(workingDir, "admin", "public"), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(workingDir, "admin", "public", "index.php"), nil, 0644)).To(Succeed())
+
+		config = phphttpd.NewConfig(scribe.NewEmitter(bytes.NewBuffer(nil)))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	it("renders a single server by default", func() {
+		metadata, err := config.Write(layerDir, workingDir)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := os.ReadFile(metadata.ConfigPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).NotTo(ContainSubstring("VirtualHost"))
+		Expect(string(contents)).NotTo(ContainSubstring("Alias"))
+	})
+
+	context("when .httpd.toml declares virtual hosts", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.toml"), []byte(`
+[[hosts]]
+server_name = "admin.example.com"
+server_aliases = ["admin.example.org", "*.admin.example.net"]
+web_directory = "admin/public"
+front_controller = "index.php"
+`), 0644)).To(Succeed())
+		})
+
+		it("keeps the main server as the default host and renders the others", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			root := filepath.Join(workingDir, "admin", "public")
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`<VirtualHost *:${PORT}>
+    RewriteEngine On
+    RewriteOptions Inherit
+</VirtualHost>
+
+<VirtualHost *:${PORT}>
+    ServerName "admin.example.com"
+    ServerAlias "admin.example.org"
+    ServerAlias "*.admin.example.net"
+    DocumentRoot "` + root + `"
+    RewriteEngine On
+    RewriteOptions Inherit
+
+<Proxy "fcgi://127.0.0.1:9000` + root + `">
+    ProxySet disablereuse=On retry=0
+</Proxy>
+
+<Directory "` + root + `">
+    Options SymLinksIfOwnerMatch
+    AllowOverride All
+    Require all granted
+    FallbackResource "/index.php"
+    <Files *.php>
+        <If "-f %{REQUEST_FILENAME}">
+            SetHandler proxy:fcgi://127.0.0.1:9000
+        </If>
+    </Files>
+</Directory>
+</VirtualHost>`))
+		})
+	})
+
+	context("when .httpd.toml declares path mounts", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.toml"), []byte(`
+[[mounts]]
+path = "/admin/"
+web_directory = "admin/public"
+front_controller = "index.php"
+fpm_socket = "127.0.0.1:9001"
+`), 0644)).To(Succeed())
+		})
+
+		it("aliases the path to the web directory with its own FPM handler", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			root := filepath.Join(workingDir, "admin", "public")
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("LoadModule alias_module modules/mod_alias.so"))
+			Expect(string(contents)).To(ContainSubstring(`Alias "/admin" "` + root + `"
+
+<Proxy "fcgi://127.0.0.1:9001` + root + `">
+    ProxySet disablereuse=On retry=0
+</Proxy>
+
+<Directory "` + root + `">
+    Options SymLinksIfOwnerMatch
+    AllowOverride All
+    Require all granted
+    FallbackResource "/admin/index.php"
+    <Files *.php>
+        <If "-f %{REQUEST_FILENAME}">
+            SetHandler proxy:fcgi://127.0.0.1:9001
+        </If>
+    </Files>
+</Directory>`))
+		})
+	})
+
+	context("failure cases", func() {
+		context("when .httpd.toml is malformed", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.toml"), []byte("[[hosts]\n"), 0644)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse .httpd.toml")))
+			})
+		})
+
+		context("when a server name is invalid", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.toml"), []byte(`
+[[hosts]]
+server_name = "admin.example.com\"\nInclude /etc"
+web_directory = "admin/public"
+`), 0644)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(".httpd.toml: hosts[0]: invalid server name")))
+			})
+		})
+
+		context("when the hosts are declared in project.toml", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "project.toml"), []byte(`
+[[php.httpd.hosts]]
+server_name = "admin.example.com"
+web_directory = "admin/missing"
+`), 0644)).To(Succeed())
+			})
+
+			it("names project.toml in the error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError("project.toml: hosts[0] (admin.example.com): web_directory does not exist: admin/missing"))
+			})
+		})
+
+		context("when a web directory is outside of the app", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.toml"), []byte(`
+[[hosts]]
+server_name = "admin.example.com"
+web_directory = "../admin"
+`), 0644)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`.httpd.toml: hosts[0] (admin.example.com): web_directory must be a path inside the application: "../admin"`)))
+			})
+		})
+
+		context("when a web directory does not exist", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.toml"), []byte(`
+[[mounts]]
+path = "/api"
+web_directory = "api/public"
+`), 0644)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(".httpd.toml: mounts[0] (/api): web_directory does not exist: api/public")))
+			})
+		})
+
+		context("when a mount path is invalid", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.toml"), []byte(`
+[[mounts]]
+path = "/"
+web_directory = "admin/public"
+`), 0644)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`.httpd.toml: mounts[0]: path must be a request path below '/': "/"`)))
+			})
+		})
+
+		context("when an FPM socket is invalid", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.toml"), []byte(`
+[[hosts]]
+server_name = "admin.example.com"
+web_directory = "admin/public"
+fpm_socket = "127.0.0.1:9001\" evil"
+`), 0644)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`.httpd.toml: hosts[0] (admin.example.com): fpm_socket must be host:port or the absolute path of a unix socket: "127.0.0.1:9001\" evil"`)))
+			})
+		})
+
+		context("when a mount's FPM socket is invalid", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.toml"), []byte(`
+[[mounts]]
+path = "/admin"
+web_directory = "admin/public"
+fpm_socket = "php-fpm.sock"
+`), 0644)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(`.httpd.toml: mounts[0] (/admin): fpm_socket must be host:port or the absolute path of a unix socket: "php-fpm.sock"`)))
+			})
+		})
+
+		context("when a front controller does not exist", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, ".httpd.toml"), []byte(`
+[[mounts]]
+path = "/admin"
+web_directory = "admin/public"
+front_controller = "app.php"
+`), 0644)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring(".httpd.toml: mounts[0] (/admin): front_controller does not exist: app.php")))
+			})
+		})
+	})
+}
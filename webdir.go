@@ -0,0 +1,134 @@
+package phphttpd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultWebDirectory = "htdocs"
+
+// webDirectoryCandidates are the web directories of common PHP frameworks,
+// in the order they are tried when the default one does not exist.
+var webDirectoryCandidates = []string{"public", "web", "htdocs", "www"}
+
+// loadWebDirectory returns the web directory relative to the application
+// root. When none is configured and the default does not exist, a directory
+// commonly used by PHP frameworks is used instead, or the application root
+// itself when it contains an index.php.
+func (c Config) loadWebDirectory(s settings, workingDir string) (string, error) {
+	webDir, configured := s.lookup("BP_PHP_WEB_DIR")
+	if webDir == "" {
+		webDir = defaultWebDirectory
+		configured = false
+	}
+
+	webDir, err := validateWebDirectory(s, webDir)
+	if err != nil {
+		return "", err
+	}
+
+	verify, err := s.lookupBool("BP_PHP_HTTPD_VERIFY_WEB_DIR", true)
+	if err != nil {
+		return "", err
+	}
+	if !verify {
+		return webDir, nil
+	}
+
+	exists, err := isDirectory(filepath.Join(workingDir, webDir))
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return webDir, nil
+	}
+
+	candidate, err := inferWebDirectory(workingDir)
+	if err != nil {
+		return "", err
+	}
+
+	if configured || candidate == "" {
+		message := fmt.Sprintf("web directory %s does not exist", webDir)
+		if configured {
+			message = fmt.Sprintf("web directory %s set by %s does not exist", webDir, s.describe("BP_PHP_WEB_DIR"))
+		}
+
+		found, err := listDirectories(workingDir)
+		if err != nil {
+			return "", err
+		}
+		if len(found) == 0 {
+			message += ", the application contains no directories"
+		} else {
+			message += fmt.Sprintf(", the application contains: %s", strings.Join(found, ", "))
+		}
+
+		return "", fmt.Errorf("%s; set $BP_PHP_WEB_DIR to the directory to serve, or $BP_PHP_HTTPD_VERIFY_WEB_DIR to false if it is created later in the build", message)
+	}
+
+	c.logger.Subprocess("Web directory %s does not exist, using %s", webDir, candidate)
+
+	return candidate, nil
+}
+
+// inferWebDirectory returns the first of the common web directories that
+// exists in the application, "." when the application root contains an
+// index.php, or the empty string.
+func inferWebDirectory(workingDir string) (string, error) {
+	for _, candidate := range webDirectoryCandidates {
+		exists, err := isDirectory(filepath.Join(workingDir, candidate))
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return candidate, nil
+		}
+	}
+
+	_, err := os.Stat(filepath.Join(workingDir, "index.php"))
+	if err == nil {
+		return ".", nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		// untested
+		return "", fmt.Errorf("failed to stat index.php: %w", err)
+	}
+
+	return "", nil
+}
+
+// listDirectories returns the names of the top-level, non-hidden directories
+// of the application.
+func listDirectories(workingDir string) ([]string, error) {
+	entries, err := os.ReadDir(workingDir)
+	if err != nil {
+		// untested
+		return nil, fmt.Errorf("failed to read application directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			names = append(names, entry.Name())
+		}
+	}
+
+	return names, nil
+}
+
+func isDirectory(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		// untested
+		return false, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	return info.IsDir(), nil
+}
@@ -0,0 +1,136 @@
+package phphttpd_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	phphttpd "github.com/paketo-buildpacks/php-httpd"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testWebDirectory(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		workingDir string
+		buffer     *bytes.Buffer
+		config     phphttpd.Config
+	)
+
+	it.Before(func() {
+		var err error
+		layerDir, err = os.MkdirTemp("", "php-httpd-layer")
+		Expect(err).NotTo(HaveOccurred())
+
+		workingDir, err = os.MkdirTemp("", "workingDir")
+		Expect(err).NotTo(HaveOccurred())
+
+		buffer = bytes.NewBuffer(nil)
+		config = phphttpd.NewConfig(scribe.NewEmitter(buffer))
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(layerDir)).To(Succeed())
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	context("when htdocs does not exist", func() {
+		it("uses the first common web directory that does", func() {
+			Expect(os.Mkdir(filepath.Join(workingDir, "www"), os.ModePerm)).To(Succeed())
+			Expect(os.Mkdir(filepath.Join(workingDir, "web"), os.ModePerm)).To(Succeed())
+
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`DocumentRoot "` + filepath.Join(workingDir, "web") + `"`))
+			Expect(buffer.String()).To(ContainSubstring("Web directory htdocs does not exist, using web"))
+			Expect(buffer.String()).To(MatchRegexp(`web_directory\s+inferred\s+web`))
+		})
+
+		it("uses the application root when it contains an index.php", func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "index.php"), nil, 0600)).To(Succeed())
+
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`DocumentRoot "` + workingDir + `"`))
+			Expect(buffer.String()).To(ContainSubstring("Web directory htdocs does not exist, using ."))
+		})
+	})
+
+	context("when $BP_PHP_HTTPD_VERIFY_WEB_DIR is false", func() {
+		it.Before(func() {
+			Expect(os.Setenv("BP_PHP_HTTPD_VERIFY_WEB_DIR", "false")).To(Succeed())
+			Expect(os.Setenv("BP_PHP_WEB_DIR", "dist")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_PHP_HTTPD_VERIFY_WEB_DIR")).To(Succeed())
+			Expect(os.Unsetenv("BP_PHP_WEB_DIR")).To(Succeed())
+		})
+
+		it("uses the web directory even though it does not exist yet", func() {
+			metadata, err := config.Write(layerDir, workingDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(metadata.ConfigPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring(`DocumentRoot "` + filepath.Join(workingDir, "dist") + `"`))
+		})
+	})
+
+	context("failure cases", func() {
+		context("when the configured web directory does not exist", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_WEB_DIR", "dist")).To(Succeed())
+				Expect(os.Mkdir(filepath.Join(workingDir, "public"), os.ModePerm)).To(Succeed())
+				Expect(os.Mkdir(filepath.Join(workingDir, "src"), os.ModePerm)).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_WEB_DIR")).To(Succeed())
+			})
+
+			it("returns an error listing the application's directories", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError("web directory dist set by $BP_PHP_WEB_DIR does not exist, the application contains: public, src; set $BP_PHP_WEB_DIR to the directory to serve, or $BP_PHP_HTTPD_VERIFY_WEB_DIR to false if it is created later in the build"))
+			})
+		})
+
+		context("when no web directory can be inferred", func() {
+			it.Before(func() {
+				Expect(os.Mkdir(filepath.Join(workingDir, "src"), os.ModePerm)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("web directory htdocs does not exist, the application contains: src;")))
+			})
+		})
+
+		context("when $BP_PHP_HTTPD_VERIFY_WEB_DIR is not a boolean", func() {
+			it.Before(func() {
+				Expect(os.Setenv("BP_PHP_HTTPD_VERIFY_WEB_DIR", "maybe")).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Unsetenv("BP_PHP_HTTPD_VERIFY_WEB_DIR")).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := config.Write(layerDir, workingDir)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse $BP_PHP_HTTPD_VERIFY_WEB_DIR into boolean")))
+			})
+		})
+	})
+}